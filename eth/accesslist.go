@@ -0,0 +1,148 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide the EIP-2930 access lists of invoking created by eth_createAccessList
+ * @file accesslist.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// accessListSig caches the access list by function signature,
+	// accessListArgs caches it by function signature and args
+	accessListSig  = "sig"
+	accessListArgs = "args"
+)
+
+// accessListResult is the result of eth_createAccessList
+type accessListResult struct {
+	AccessList types.AccessList `json:"accessList"`
+	GasUsed    hexutil.Uint64   `json:"gasUsed"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// accessListEntry is the cached access list and the gas limit of transaction with it
+type accessListEntry struct {
+	list types.AccessList
+	gas  uint64
+}
+
+// accessListCache caches the access lists of vm by the shape of invoking
+type accessListCache struct {
+	mu      sync.Mutex
+	entries map[string]*accessListEntry
+}
+
+// parseAccessListOption parses the option `accesslist`, empty is returned if access list is not used
+func parseAccessListOption(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return accessListSig, nil
+		}
+		return "", nil
+	case string:
+		if v != accessListSig && v != accessListArgs {
+			return "", errors.New("option `accesslist` value error: " + v)
+		}
+		return v, nil
+	default:
+		return "", errors.New("option `accesslist` type error: " + reflect.TypeOf(value).Name())
+	}
+}
+
+// accessListKey returns the key of invoking in cache, the created contract is keyed without address
+func accessListKey(mode string, inv *invocation, args []interface{}) string {
+	key := "create:" + inv.sig
+	if inv.to != nil {
+		key = inv.to.Hex() + ":" + inv.sig
+	}
+	if mode == accessListArgs {
+		key += fmt.Sprintf(":%v", args)
+	}
+	return key
+}
+
+// createAccessList creates the access list of transaction by eth_createAccessList,
+// the gas limit is the gas used with the access list multiplied by the multiplier of estimator
+func (e *ETH) createAccessList(from common.Address, to *common.Address, data []byte) (*accessListEntry, error) {
+	args := map[string]interface{}{
+		"from":  from,
+		"value": (*hexutil.Big)(big.NewInt(0)),
+		"data":  hexutil.Bytes(data),
+	}
+	if to != nil {
+		args["to"] = to
+	}
+	res := &accessListResult{}
+	_, err := e.endpoints.call(func(ep *endpoint) error {
+		return ep.rpcClient.CallContext(context.Background(), res, "eth_createAccessList", args)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != "" {
+		return nil, errors.New(res.Error)
+	}
+	list := res.AccessList
+	if list == nil {
+		// the empty access list still makes the transaction typed
+		list = types.AccessList{}
+	}
+	return &accessListEntry{list: list, gas: uint64(float64(res.GasUsed) * e.gas.multiplier)}, nil
+}
+
+// invocationGasLimit returns the gas limit of invocation, the access list is attached to invocation
+// and its gas is used unless the gas limit is set explicitly if access list is used
+func (e *ETH) invocationGasLimit(from common.Address, fn string, args []interface{}, inv *invocation) (uint64, error) {
+	if e.op.accessList == "" {
+		return e.invokeGasLimit(from, inv.to, fn, inv.sig, inv.data), nil
+	}
+	key := accessListKey(e.op.accessList, inv, args)
+	e.accessLists.mu.Lock()
+	entry, ok := e.accessLists.entries[key]
+	e.accessLists.mu.Unlock()
+	if !ok {
+		var err error
+		if entry, err = e.createAccessList(from, inv.to, inv.data); err != nil {
+			return 0, fmt.Errorf("create access list of %v: %v", fn, err)
+		}
+		e.Logger.Infof("access list of %v has %v addresses and %v storage keys, gas limit %v",
+			key, len(entry.list), entry.list.StorageKeys(), entry.gas)
+		e.accessLists.mu.Lock()
+		e.accessLists.entries[key] = entry
+		e.accessLists.mu.Unlock()
+	}
+	inv.accessList = entry.list
+	if limit, ok := e.explicitGasLimit(fn, inv.sig); ok {
+		return limit, nil
+	}
+	return entry.gas, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	fcom "github.com/hyperbench/hyperbench-common/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAccessListOption(t *testing.T) {
+	for value, expected := range map[interface{}]string{true: accessListSig, false: "", "sig": accessListSig, "args": accessListArgs} {
+		mode, err := parseAccessListOption(value)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, mode)
+	}
+	_, err := parseAccessListOption("slot")
+	assert.Error(t, err)
+	_, err = parseAccessListOption(float64(1))
+	assert.Error(t, err)
+}
+
+func TestAccessList(t *testing.T) {
+	client, stop := newSimulatedClient(t, storageABI, storageBIN)
+	defer stop()
+	assert.NoError(t, client.DeployContract())
+	// invoke sends set(x), confirms it and returns the transaction
+	invoke := func(x float64) *types.Transaction {
+		res := client.Invoke(fcom.Invoke{Func: "set", Args: []interface{}{x}})
+		assert.Equal(t, fcom.Success, res.Status)
+		assert.Equal(t, fcom.Confirm, client.Confirm(res).Status)
+		tx, _, err := client.ethClient().TransactionByHash(context.Background(), common.HexToHash(res.UID))
+		assert.NoError(t, err)
+		return tx
+	}
+
+	// the access list of storage slot is cached by signature
+	assert.NoError(t, client.Option(fcom.Option{"accesslist": true, "txtype": "legacy"}))
+	tx := invoke(1)
+	assert.Equal(t, uint8(types.AccessListTxType), tx.Type())
+	assert.Equal(t, types.AccessList{{Address: client.contract.Address, StorageKeys: []common.Hash{{}}}}, tx.AccessList())
+	invoke(2)
+	assert.Len(t, client.accessLists.entries, 1)
+
+	// the access list is cached by args and sent by dynamic fee transaction
+	assert.NoError(t, client.Option(fcom.Option{"accesslist": "args", "txtype": "dynamic"}))
+	tx = invoke(3)
+	assert.Equal(t, uint8(types.DynamicFeeTxType), tx.Type())
+	assert.Len(t, tx.AccessList(), 1)
+	invoke(3)
+	invoke(4)
+	assert.Len(t, client.accessLists.entries, 3)
+
+	// the transaction without access list is not changed
+	assert.NoError(t, client.Option(fcom.Option{"accesslist": false, "txtype": "legacy"}))
+	tx = invoke(5)
+	assert.Equal(t, uint8(types.LegacyTxType), tx.Type())
+
+	// the access list transaction is signed before london
+	client.signer = chainSigner(client.chainID, false)
+	assert.NoError(t, client.Option(fcom.Option{"accesslist": true, "txtype": "legacy"}))
+	tx = invoke(6)
+	assert.Equal(t, uint8(types.AccessListTxType), tx.Type())
+}
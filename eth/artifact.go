@@ -0,0 +1,504 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Load compiled contracts from abi and bin files, framework artifacts and solc output
+ * @file artifact.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// placeholderLen is the length of library placeholder in hex bytecode
+	placeholderLen = 40
+	// placeholderNameLen is the max length of library name in placeholder of solc before 0.5
+	placeholderNameLen = 36
+	// addrExt is the extension of the file keeping the address of deployed contract
+	addrExt = ".addr"
+)
+
+// artifact is a compiled contract
+type artifact struct {
+	// name is the contract name, fq is the fully qualified name `source:name` if known
+	name string
+	fq   string
+	abi  string
+	// bin is the hex bytecode without 0x, it may contain library placeholders
+	bin string
+	// links maps the placeholders in bin to the libraries, keyed by fully qualified name or name
+	links map[string]string
+}
+
+// libraryName returns the contract name of fully qualified name `source:name`
+func libraryName(fq string) string {
+	return fq[strings.LastIndex(fq, ":")+1:]
+}
+
+// hashPlaceholder returns the placeholder of library since solc 0.5
+func hashPlaceholder(fq string) string {
+	return "__$" + hex.EncodeToString(crypto.Keccak256([]byte(fq)))[:34] + "$__"
+}
+
+// namePlaceholder returns the placeholder of library before solc 0.5 and of truffle
+func namePlaceholder(fq string) string {
+	if len(fq) > placeholderNameLen {
+		fq = fq[:placeholderNameLen]
+	}
+	return "__" + fq + strings.Repeat("_", placeholderLen-2-len(fq))
+}
+
+// scanPlaceholders returns the placeholders in bytecode
+func scanPlaceholders(bin string) []string {
+	var placeholders []string
+	seen := make(map[string]bool)
+	for i := strings.Index(bin, "__"); i >= 0 && i+placeholderLen <= len(bin); {
+		p := bin[i : i+placeholderLen]
+		if !seen[p] {
+			seen[p] = true
+			placeholders = append(placeholders, p)
+		}
+		next := strings.Index(bin[i+placeholderLen:], "__")
+		if next < 0 {
+			break
+		}
+		i += placeholderLen + next
+	}
+	return placeholders
+}
+
+// link replaces the placeholders of libraries by addresses, lookup returns the address of library
+func (a *artifact) link(lookup func(library string) (common.Address, bool)) (string, error) {
+	bin := a.bin
+	for _, p := range scanPlaceholders(bin) {
+		library, ok := a.links[p]
+		if !ok {
+			return "", fmt.Errorf("unknown library placeholder %v in %v", p, a.name)
+		}
+		address, ok := lookup(library)
+		if !ok {
+			return "", fmt.Errorf("address of library %v used by %v is not found", library, a.name)
+		}
+		bin = strings.Replace(bin, p, hex.EncodeToString(address.Bytes()), -1)
+	}
+	return bin, nil
+}
+
+// libraries are the addresses of libraries keyed by fully qualified name or name
+type libraries map[string]common.Address
+
+// parseLibraries parses the libraries in form of `name=address`
+func parseLibraries(entries []string) (libraries, error) {
+	libs := make(libraries, len(entries))
+	for _, entry := range entries {
+		i := strings.LastIndex(entry, "=")
+		if i < 0 || !common.IsHexAddress(strings.TrimSpace(entry[i+1:])) {
+			return nil, errors.New("invalid library: " + entry)
+		}
+		libs[strings.TrimSpace(entry[:i])] = common.HexToAddress(strings.TrimSpace(entry[i+1:]))
+	}
+	return libs, nil
+}
+
+// lookup returns the address of library by fully qualified name or name
+func (l libraries) lookup(library string) (common.Address, bool) {
+	for _, key := range []string{library, libraryName(library)} {
+		if address, ok := l[key]; ok {
+			return address, true
+		}
+	}
+	for key, address := range l {
+		// the name in placeholder of old solc is truncated
+		if len(library) == placeholderNameLen && strings.HasPrefix(key, library) {
+			return address, true
+		}
+		// truffle placeholder only contains the name
+		if libraryName(key) == library {
+			return address, true
+		}
+	}
+	return common.Address{}, false
+}
+
+// artifactFile is the union of json formats of hardhat, foundry, truffle and solc --combined-json
+type artifactFile struct {
+	ContractName string          `json:"contractName"`
+	SourceName   string          `json:"sourceName"`
+	ABI          json.RawMessage `json:"abi"`
+	// Bytecode is a hex string of hardhat and truffle, or an object of foundry
+	Bytecode       json.RawMessage            `json:"bytecode"`
+	LinkReferences map[string]json.RawMessage `json:"linkReferences"`
+	AST            *struct {
+		AbsolutePath string `json:"absolutePath"`
+	} `json:"ast"`
+	Contracts map[string]struct {
+		ABI json.RawMessage `json:"abi"`
+		Bin string          `json:"bin"`
+	} `json:"contracts"`
+}
+
+// foundryBytecode is the bytecode object of foundry
+type foundryBytecode struct {
+	Object         string                     `json:"object"`
+	LinkReferences map[string]json.RawMessage `json:"linkReferences"`
+}
+
+// loadArtifacts loads all contracts in directory recursively. Supported are
+// the `.abi` and `.bin` files with the same name, hardhat, foundry and truffle
+// artifacts, and the output of `solc --combined-json abi,bin`.
+func loadArtifacts(dir string) ([]*artifact, error) {
+	var (
+		artifacts []*artifact
+		abis      = make(map[string]string)
+		bins      = make(map[string]string)
+	)
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := info.Name()
+		if info.IsDir() {
+			// debug and build info of hardhat are not contracts
+			if file != dir && (name == "build-info" || name == "node_modules" || name == "cache") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		switch {
+		case ext == ".abi" || ext == ".bin":
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			if ext == ".abi" {
+				abis[base] = string(data)
+			} else {
+				bins[base] = strings.TrimSpace(string(data))
+			}
+		case ext == ".json" && !strings.HasSuffix(name, ".dbg.json"):
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			loaded, err := parseArtifact(base, data)
+			if err != nil {
+				return fmt.Errorf("parse %v failed: %v", file, err)
+			}
+			artifacts = append(artifacts, loaded...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// a single pair of abi and bin files is used regardless of names
+	if len(abis) == 1 && len(bins) == 1 {
+		for name, abiJSON := range abis {
+			for _, bin := range bins {
+				bins = map[string]string{name: bin}
+				abis = map[string]string{name: abiJSON}
+			}
+		}
+	}
+	names := make([]string, 0, len(abis))
+	for name := range abis {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		artifacts = append(artifacts, &artifact{name: name, abi: abis[name], bin: strip0x(bins[name])})
+	}
+	resolveLinks(artifacts)
+	return artifacts, nil
+}
+
+// parseArtifact parses the contracts in json file named base, the files which are not artifacts are ignored
+func parseArtifact(base string, data []byte) ([]*artifact, error) {
+	var f artifactFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		// not a json object
+		return nil, nil
+	}
+
+	// solc --combined-json
+	if len(f.Contracts) > 0 {
+		var artifacts []*artifact
+		for fq, c := range f.Contracts {
+			abiJSON, err := rawABI(c.ABI)
+			if err != nil {
+				return nil, err
+			}
+			artifacts = append(artifacts, &artifact{name: libraryName(fq), fq: fq, abi: abiJSON, bin: strip0x(c.Bin)})
+		}
+		sort.Slice(artifacts, func(i, j int) bool {
+			return artifacts[i].fq < artifacts[j].fq
+		})
+		return artifacts, nil
+	}
+	if len(f.ABI) == 0 {
+		return nil, nil
+	}
+
+	abiJSON, err := rawABI(f.ABI)
+	if err != nil {
+		return nil, err
+	}
+	a := &artifact{name: f.ContractName, abi: abiJSON, links: make(map[string]string)}
+	if a.name == "" {
+		// foundry names the artifact by contract
+		a.name = base
+	}
+	source := f.SourceName
+	if source == "" && f.AST != nil {
+		source = f.AST.AbsolutePath
+	}
+	if source != "" {
+		a.fq = source + ":" + a.name
+	}
+
+	refs := f.LinkReferences
+	if len(f.Bytecode) > 0 && f.Bytecode[0] == '{' {
+		var b foundryBytecode
+		if err := json.Unmarshal(f.Bytecode, &b); err != nil {
+			return nil, err
+		}
+		a.bin, refs = b.Object, b.LinkReferences
+	} else if len(f.Bytecode) > 0 {
+		if err := json.Unmarshal(f.Bytecode, &a.bin); err != nil {
+			return nil, err
+		}
+	}
+	a.bin = strip0x(a.bin)
+	// link references are keyed by source and library name
+	for source, raw := range refs {
+		var libs map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &libs); err != nil {
+			return nil, err
+		}
+		for lib := range libs {
+			fq := source + ":" + lib
+			a.links[hashPlaceholder(fq)] = fq
+			a.links[namePlaceholder(fq)] = fq
+		}
+	}
+	return []*artifact{a}, nil
+}
+
+// rawABI returns the abi in json, solc before 0.8 outputs it as a json string
+func rawABI(raw json.RawMessage) (string, error) {
+	if len(raw) > 0 && raw[0] == '"' {
+		var abiJSON string
+		err := json.Unmarshal(raw, &abiJSON)
+		return abiJSON, err
+	}
+	return string(raw), nil
+}
+
+func strip0x(bin string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(bin, "0x"), "0X")
+}
+
+// resolveLinks resolves the placeholders without link references by names of all artifacts
+func resolveLinks(artifacts []*artifact) {
+	known := make(map[string]string)
+	for _, a := range artifacts {
+		names := []string{a.name}
+		if a.fq != "" {
+			names = append(names, a.fq)
+		}
+		for _, name := range names {
+			known[hashPlaceholder(name)] = name
+			known[namePlaceholder(name)] = name
+		}
+	}
+	for _, a := range artifacts {
+		if a.links == nil {
+			a.links = make(map[string]string)
+		}
+		for _, p := range scanPlaceholders(a.bin) {
+			if _, ok := a.links[p]; ok {
+				continue
+			}
+			if name, ok := known[p]; ok {
+				a.links[p] = name
+			} else if !strings.HasPrefix(p, "__$") {
+				// the name in placeholder is the library even if its artifact is absent
+				a.links[p] = strings.TrimRight(p[2:], "_")
+			}
+		}
+	}
+}
+
+// findArtifact returns the artifact by fully qualified name or name
+func findArtifact(artifacts []*artifact, name string) *artifact {
+	for _, a := range artifacts {
+		if a.fq == name || (a.fq != "" && len(name) == placeholderNameLen && strings.HasPrefix(a.fq, name)) {
+			return a
+		}
+	}
+	for _, a := range artifacts {
+		if strings.EqualFold(a.name, libraryName(name)) {
+			return a
+		}
+	}
+	return nil
+}
+
+// mainArtifact returns the artifact to deploy, it is chosen by name if given,
+// otherwise it is the only deployable artifact not used as library by others
+func mainArtifact(artifacts []*artifact, name string) (*artifact, error) {
+	if name != "" {
+		if a := findArtifact(artifacts, name); a != nil {
+			return a, nil
+		}
+		return nil, errors.New("contract is not found: " + name)
+	}
+	used := make(map[*artifact]bool)
+	for _, a := range artifacts {
+		for _, library := range a.links {
+			if lib := findArtifact(artifacts, library); lib != nil {
+				used[lib] = true
+			}
+		}
+	}
+	var candidates []*artifact
+	for _, a := range artifacts {
+		if a.bin != "" && !used[a] {
+			candidates = append(candidates, a)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		if len(artifacts) == 1 {
+			return artifacts[0], nil
+		}
+		return nil, errors.New("no contract to deploy")
+	case 1:
+		return candidates[0], nil
+	default:
+		names := make([]string, len(candidates))
+		for i, a := range candidates {
+			names[i] = a.name
+		}
+		return nil, errors.New("contract.name is required to choose one of contracts: " + strings.Join(names, ", "))
+	}
+}
+
+// maxLinkDepth is the max depth of libraries linking other libraries
+const maxLinkDepth = 8
+
+// linkArtifact links the bytecode of artifact with libraries, a library without address
+// is deployed from its artifact and its address is added to libraries
+func (e *ETH) linkArtifact(a *artifact, artifacts []*artifact, libs libraries) (string, error) {
+	return e.linkDepth(a, artifacts, libs, 0)
+}
+
+func (e *ETH) linkDepth(a *artifact, artifacts []*artifact, libs libraries, depth int) (string, error) {
+	if depth > maxLinkDepth {
+		return "", errors.New("libraries are linked too deep: " + a.name)
+	}
+	var linkErr error
+	bin, err := a.link(func(library string) (common.Address, bool) {
+		if address, ok := libs.lookup(library); ok {
+			return address, true
+		}
+		lib := findArtifact(artifacts, library)
+		if lib == nil || lib == a || lib.bin == "" {
+			return common.Address{}, false
+		}
+		bin, err := e.linkDepth(lib, artifacts, libs, depth+1)
+		if err != nil {
+			linkErr = err
+			return common.Address{}, false
+		}
+		parsed, err := parseABI(lib.abi)
+		if err != nil {
+			linkErr = err
+			return common.Address{}, false
+		}
+		address, tx, err := e.deploy(parsed, bin)
+		if err == nil {
+			err = e.waitDeployed(address, tx)
+		}
+		if err != nil {
+			linkErr = err
+			return common.Address{}, false
+		}
+		e.Logger.Noticef("deploy library %v at %v", library, address.Hex())
+		libs[library] = address
+		return address, true
+	})
+	if linkErr != nil {
+		return "", linkErr
+	}
+	return bin, err
+}
+
+// addressFile returns the `.addr` file keeping the address of deployed contract in dir,
+// the file named by contract is preferred if there are several. Empty is returned if there is no file.
+func addressFile(dir, name string) (string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+addrExt))
+	if err != nil || len(files) == 0 {
+		return "", err
+	}
+	if len(files) == 1 {
+		return files[0], nil
+	}
+	file := filepath.Join(dir, name+addrExt)
+	if _, err = os.Stat(file); err != nil {
+		return "", fmt.Errorf("%v address files, %v is not found", len(files), name+addrExt)
+	}
+	return file, nil
+}
+
+// readAddress reads the address of deployed contract from file, false is returned if the file is empty
+func readAddress(file string) (common.Address, bool, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return common.Address{}, false, err
+	}
+	address := strings.TrimSpace(string(data))
+	if address == "" {
+		// an empty file is ignored and the contract is deployed
+		return common.Address{}, false, nil
+	}
+	if !common.IsHexAddress(address) {
+		return common.Address{}, false, fmt.Errorf("invalid address in %v: %v", file, address)
+	}
+	return common.HexToAddress(address), true, nil
+}
+
+// writeAddress writes the address of deployed contract to the `.addr` file named by contract in dir
+func writeAddress(dir, name string, address common.Address) error {
+	return ioutil.WriteFile(filepath.Join(dir, name+addrExt), []byte(address.Hex()), 0644)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testLibrary = "contracts/Math.sol:MathLib"
+	testABI     = `[{"inputs":[{"name":"x","type":"uint256"}],"stateMutability":"nonpayable","type":"constructor"}]`
+)
+
+func writeArtifact(t *testing.T, dir, name, content string) {
+	file := filepath.Join(dir, name)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+	assert.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+}
+
+func TestLoadArtifacts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artifact")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// hardhat contract using library
+	bin := "0x6080" + hashPlaceholder(testLibrary) + "6000"
+	writeArtifact(t, dir, "contracts/Token.sol/Token.json", `{"_format":"hh-sol-artifact-1","contractName":"Token",
+		"sourceName":"contracts/Token.sol","abi":`+testABI+`,"bytecode":"`+bin+`",
+		"linkReferences":{"contracts/Math.sol":{"MathLib":[{"start":2,"length":20}]}}}`)
+	writeArtifact(t, dir, "contracts/Token.sol/Token.dbg.json", `{"_format":"hh-sol-dbg-1","buildInfo":"x"}`)
+	writeArtifact(t, dir, "build-info/x.json", `{"output":{"contracts":{}}}`)
+	// foundry library
+	writeArtifact(t, dir, "out/Math.sol/MathLib.json", `{"abi":[],"bytecode":{"object":"0x6001","linkReferences":{}},
+		"ast":{"absolutePath":"contracts/Math.sol"}}`)
+
+	artifacts, err := loadArtifacts(dir)
+	assert.NoError(t, err)
+	assert.Len(t, artifacts, 2)
+	main, err := mainArtifact(artifacts, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "Token", main.name)
+	assert.Equal(t, testABI, main.abi)
+	lib := findArtifact(artifacts, testLibrary)
+	assert.NotNil(t, lib)
+	assert.Equal(t, "6001", lib.bin)
+
+	address := common.HexToAddress("0x74d366e0649a91395bb122c005917644382b9452")
+	linked, err := main.link(libraries{"MathLib": address}.lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, "608074d366e0649a91395bb122c005917644382b94526000", linked)
+	_, err = main.link(libraries{}.lookup)
+	assert.Error(t, err)
+}
+
+func TestLoadCombinedJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artifact")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// solc before 0.8 outputs abi as string, placeholders of solc before 0.5 contain names
+	writeArtifact(t, dir, "combined.json", `{"contracts":{
+		"contracts/Token.sol:Token":{"abi":"[]","bin":"6080`+namePlaceholder(testLibrary)+`"},
+		"contracts/Math.sol:MathLib":{"abi":"[]","bin":"6001"}},"version":"0.4.26"}`)
+	// truffle placeholders contain library name
+	writeArtifact(t, dir, "build/contracts/Vault.json", `{"contractName":"Vault","abi":[],
+		"bytecode":"0x6080`+namePlaceholder("MathLib")+`"}`)
+	writeArtifact(t, dir, "package.json", `{"name":"contracts"}`)
+
+	artifacts, err := loadArtifacts(dir)
+	assert.NoError(t, err)
+	assert.Len(t, artifacts, 3)
+	_, err = mainArtifact(artifacts, "")
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "Token"))
+
+	libs, err := parseLibraries([]string{testLibrary + " = 0x74d366e0649a91395bb122c005917644382b9452"})
+	assert.NoError(t, err)
+	for _, name := range []string{"token", "Vault"} {
+		a, err := mainArtifact(artifacts, name)
+		assert.NoError(t, err)
+		linked, err := a.link(libs.lookup)
+		assert.NoError(t, err)
+		assert.Equal(t, "608074d366e0649a91395bb122c005917644382b9452", linked)
+	}
+	_, err = parseLibraries([]string{"MathLib"})
+	assert.Error(t, err)
+}
+
+func TestLoadABIAndBin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artifact")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeArtifact(t, dir, "storage.abi", "[]")
+	writeArtifact(t, dir, "Storage.bin", "0x6080\n")
+	artifacts, err := loadArtifacts(dir)
+	assert.NoError(t, err)
+	main, err := mainArtifact(artifacts, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", main.abi)
+	assert.Equal(t, "6080", main.bin)
+}
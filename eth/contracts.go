@@ -0,0 +1,284 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide the deployment of multiple contracts and the routing of functions to them
+ * @file contracts.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/viper"
+)
+
+// addressRef matches the reference `${Name}` to the address of contract deployed earlier
+var addressRef = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// contractEntry is a contract declared in `contract.deploy` of eth.toml
+type contractEntry struct {
+	name string
+	// args are the constructor args, they may reference the addresses of contracts declared before
+	args    []interface{}
+	hasArgs bool
+}
+
+// parseContractEntries parses `contract.deploy`, an array of tables with `name` and `args`
+func parseContractEntries(value interface{}) ([]contractEntry, error) {
+	if value == nil {
+		return nil, nil
+	}
+	list, ok := luaList(value)
+	if !ok {
+		return nil, fmt.Errorf("invalid contracts to deploy: %v", value)
+	}
+	entries := make([]contractEntry, 0, len(list))
+	seen := make(map[string]bool, len(list))
+	for _, elem := range list {
+		table, ok := elem.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid contract to deploy: %v", elem)
+		}
+		var entry contractEntry
+		for k, v := range table {
+			switch strings.ToLower(k) {
+			case "name":
+				entry.name, _ = v.(string)
+			case "args":
+				if entry.args, ok = luaList(v); !ok {
+					return nil, fmt.Errorf("invalid args of contract %v: %v", table, v)
+				}
+				entry.hasArgs = true
+			default:
+				return nil, fmt.Errorf("unknown key of contract to deploy: %v", k)
+			}
+		}
+		if entry.name == "" {
+			return nil, fmt.Errorf("name of contract is required: %v", table)
+		}
+		if seen[entry.name] {
+			return nil, errors.New("contract is declared twice: " + entry.name)
+		}
+		seen[entry.name] = true
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// resolveRefs replaces the references `${Name}` in string args by the addresses of contracts,
+// the lists in args are resolved recursively
+func resolveRefs(args []interface{}, contracts map[string]*Contract) ([]interface{}, error) {
+	resolved := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			var err error
+			resolved[i] = addressRef.ReplaceAllStringFunc(v, func(ref string) string {
+				name := addressRef.FindStringSubmatch(ref)[1]
+				c, ok := contracts[name]
+				if !ok {
+					err = errors.New("contract is not deployed before: " + name)
+					return ref
+				}
+				return c.Address.Hex()
+			})
+			if err != nil {
+				return nil, err
+			}
+		default:
+			list, ok := luaList(v)
+			if !ok || v == nil {
+				resolved[i] = arg
+				continue
+			}
+			sub, err := resolveRefs(list, contracts)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = sub
+		}
+	}
+	return resolved, nil
+}
+
+// deployContracts deploys the contracts declared in order, the one named by `contract.name`
+// or the last one is the default contract of functions without contract name
+func (e *ETH) deployContracts(entries []contractEntry) error {
+	dir := e.BlockchainBase.ContractPath
+	artifacts, err := loadArtifacts(dir)
+	if err != nil {
+		return err
+	}
+	libs, err := parseLibraries(viper.GetStringSlice("contract.libraries"))
+	if err != nil {
+		return err
+	}
+	e.contracts = make(map[string]*Contract, len(entries))
+	for _, entry := range entries {
+		a := findArtifact(artifacts, entry.name)
+		if a == nil {
+			return errors.New("contract is not found: " + entry.name)
+		}
+		c := &Contract{Name: entry.name, ABI: a.abi, BIN: a.bin, artifact: a, artifacts: artifacts}
+		if c.parsedAbi, err = parseABI(c.ABI); err != nil {
+			return fmt.Errorf("decode abi of %v failed: %v", entry.name, err)
+		}
+		args := e.Args
+		if entry.hasArgs {
+			if args, err = resolveRefs(entry.args, e.contracts); err != nil {
+				return fmt.Errorf("constructor of %v: %v", entry.name, err)
+			}
+		}
+		// only the address file named by contract is used since there are several contracts
+		file := filepath.Join(dir, entry.name+addrExt)
+		if _, err = os.Stat(file); err != nil {
+			file = ""
+		}
+		if err = e.setupContract(c, file, args, libs); err != nil {
+			return err
+		}
+		e.contracts[entry.name] = c
+		e.contract = c
+	}
+	if name := viper.GetString("contract.name"); name != "" {
+		c, ok := e.contracts[name]
+		if !ok {
+			return errors.New("contract is not declared to deploy: " + name)
+		}
+		e.contract = c
+	}
+	return nil
+}
+
+// setupContract uses the contract at the address kept in file if file is given,
+// otherwise deploys it with constructor args and saves its address if `contract.save` is set
+func (e *ETH) setupContract(c *Contract, file string, args []interface{}, libs libraries) error {
+	if file != "" {
+		address, ok, err := readAddress(file)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if err = e.checkCode(address); err != nil {
+				return fmt.Errorf("use contract %v: %v", c.Name, err)
+			}
+			c.Address = address
+			e.Logger.Noticef("use contract %v at %v", c.Name, address.Hex())
+			// the bin is linked by the configured libraries for creating contract by invoking,
+			// it keeps the placeholders if a library is not configured
+			if bin, err := c.artifact.link(libs.lookup); err == nil {
+				c.BIN = bin
+			}
+			return nil
+		}
+	}
+
+	args, err := abiArgs(c.parsedAbi.Constructor.Inputs, args)
+	if err != nil {
+		return fmt.Errorf("constructor of %v: %v", c.Name, err)
+	}
+	c.BIN, err = e.linkArtifact(c.artifact, c.artifacts, libs)
+	if err != nil {
+		return fmt.Errorf("link %v: %v", c.Name, err)
+	}
+	address, tx, err := e.deploy(c.parsedAbi, c.BIN, args...)
+	if err == nil {
+		err = e.waitDeployed(address, tx)
+	}
+	if err != nil {
+		return fmt.Errorf("deploy %v: %v", c.Name, err)
+	}
+	c.Address = address
+	e.Logger.Noticef("deploy contract %v at %v", c.Name, address.Hex())
+	if viper.GetBool("contract.save") {
+		if err = writeAddress(e.BlockchainBase.ContractPath, c.Name, address); err != nil {
+			return fmt.Errorf("save address of %v: %v", c.Name, err)
+		}
+	}
+	return nil
+}
+
+// route returns the contract and method of function, `Name.method` is routed to the contract named Name
+// and other functions to the default contract
+func (e *ETH) route(fn string) (*Contract, abi.Method, error) {
+	c, name := e.contract, fn
+	if i := strings.Index(fn, "."); i >= 0 {
+		var ok bool
+		if c, ok = e.contracts[fn[:i]]; !ok {
+			return nil, abi.Method{}, errors.New("contract is not found: " + fn[:i])
+		}
+		name = fn[i+1:]
+	}
+	if c == nil {
+		return nil, abi.Method{}, errors.New("no contract to invoke")
+	}
+	if name == deployLabel {
+		return c, c.parsedAbi.Constructor, nil
+	}
+	method, ok := c.parsedAbi.Methods[name]
+	if !ok {
+		return nil, abi.Method{}, errors.New("method is not found: " + fn)
+	}
+	return c, method, nil
+}
+
+// routeSig returns the signature of method routed by function, it is qualified by the contract name in function
+func routeSig(fn string, method abi.Method) string {
+	return strings.TrimSuffix(fn, method.Name) + method.Sig
+}
+
+// contractOf returns the contract of function for decoding, nil if it is not found
+func (e *ETH) contractOf(fn string) *Contract {
+	c, _, err := e.route(fn)
+	if err != nil {
+		return e.contract
+	}
+	return c
+}
+
+// decodeEvents decodes the events of logs by the contracts emitting them,
+// the logs of contracts out of the address book are decoded by c.
+// The log failed to decode is logged and skipped, the rest are still decoded.
+func (e *ETH) decodeEvents(c *Contract, logs []*types.Log) []map[string]interface{} {
+	var events []map[string]interface{}
+	for _, log := range logs {
+		emitter := c
+		for _, known := range e.contracts {
+			if known.Address == log.Address {
+				emitter = known
+				break
+			}
+		}
+		decoded, err := emitter.decodeEvent(log)
+		if err != nil {
+			e.Logger.Errorf("decode log %v of transaction %v failed: %v", log.Index, log.TxHash.String(), err)
+			continue
+		}
+		if decoded != nil {
+			events = append(events, decoded)
+		}
+	}
+	return events
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	fcom "github.com/hyperbench/hyperbench-common/common"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseContractEntries(t *testing.T) {
+	entries, err := parseContractEntries(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, entries)
+	entries, err = parseContractEntries([]interface{}{
+		map[string]interface{}{"name": "Token"},
+		map[string]interface{}{"Name": "Pool", "Args": []interface{}{"${Token}", int64(1)}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []contractEntry{{name: "Token"}, {name: "Pool", args: []interface{}{"${Token}", int64(1)}, hasArgs: true}}, entries)
+	_, err = parseContractEntries([]interface{}{map[string]interface{}{"args": []interface{}{}}})
+	assert.Error(t, err)
+	_, err = parseContractEntries([]interface{}{map[string]interface{}{"name": "Token"}, map[string]interface{}{"name": "Token"}})
+	assert.Error(t, err)
+	_, err = parseContractEntries([]interface{}{map[string]interface{}{"name": "Token", "value": 1}})
+	assert.Error(t, err)
+
+	token := &Contract{Address: common.HexToAddress("0x74d366e0649a91395bb122c005917644382b9452")}
+	args, err := resolveRefs([]interface{}{"${Token}", []interface{}{"${Token}"}, float64(1)}, map[string]*Contract{"Token": token})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{token.Address.Hex(), []interface{}{token.Address.Hex()}, float64(1)}, args)
+	_, err = resolveRefs([]interface{}{"${Pool}"}, map[string]*Contract{"Token": token})
+	assert.Error(t, err)
+}
+
+func TestDeployContracts(t *testing.T) {
+	client, stop := newSimulatedClient(t, storageABI, storageBIN)
+	defer stop()
+	dir := client.BlockchainBase.ContractPath
+	writeArtifact(t, dir, "second.abi", storageABI)
+	writeArtifact(t, dir, "second.bin", storageBIN)
+	viper.Set("contract.deploy", []interface{}{
+		map[string]interface{}{"name": "contract"},
+		map[string]interface{}{"name": "second"},
+	})
+	defer viper.Set("contract.deploy", nil)
+	viper.Set("contract.name", "contract")
+	defer viper.Set("contract.name", "")
+
+	// contracts are deployed in order, the default one is named by `contract.name`
+	assert.NoError(t, client.DeployContract())
+	assert.Len(t, client.contracts, 2)
+	assert.Equal(t, "contract", client.contract.Name)
+	first, second := client.contracts["contract"].Address, client.contracts["second"].Address
+	assert.NotEqual(t, first, second)
+
+	// workers get the address book by context
+	ctx, err := client.GetContext()
+	assert.NoError(t, err)
+	msg := &Msg{}
+	assert.NoError(t, json.Unmarshal([]byte(ctx), msg))
+	assert.Equal(t, second, msg.Contracts["second"].Address)
+	client.contract, client.contracts = nil, nil
+	assert.NoError(t, client.SetContext(ctx))
+	assert.Equal(t, first, client.contract.Address)
+	assert.Equal(t, client.contracts["contract"], client.contract)
+
+	// `Name.method` is routed to the named contract and others to the default one
+	res := client.Invoke(fcom.Invoke{Func: "second.set", Args: []interface{}{float64(2)}})
+	assert.Equal(t, fcom.Success, res.Status)
+	assert.Equal(t, fcom.Confirm, client.Confirm(res).Status)
+	tx, _, err := client.ethClient().TransactionByHash(context.Background(), common.HexToHash(res.UID))
+	assert.NoError(t, err)
+	assert.Equal(t, second, *tx.To())
+	res = client.Invoke(fcom.Invoke{Func: "set", Args: []interface{}{float64(1)}})
+	tx, _, err = client.ethClient().TransactionByHash(context.Background(), common.HexToHash(res.UID))
+	assert.NoError(t, err)
+	assert.Equal(t, first, *tx.To())
+	assert.Equal(t, fcom.Failure, client.Invoke(fcom.Invoke{Func: "third.set", Args: []interface{}{float64(1)}}).Status)
+}
@@ -0,0 +1,377 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide conversion between abi values and plain values used by lua scripts
+ * @file convert.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	bigIntType  = reflect.TypeOf(&big.Int{})
+	addressType = reflect.TypeOf(common.Address{})
+	hashType    = reflect.TypeOf(common.Hash{})
+)
+
+// plainValue converts a value decoded by abi to the plain one which can be passed to lua and json,
+// big integers are converted to decimal strings, addresses and bytes to hex strings,
+// arrays to slices and tuples to maps keyed by component name
+func plainValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return plainReflect(reflect.ValueOf(v))
+}
+
+func plainReflect(v reflect.Value) interface{} {
+	switch v.Type() {
+	case bigIntType:
+		if v.IsNil() {
+			return nil
+		}
+		return v.Interface().(*big.Int).String()
+	case addressType:
+		return v.Interface().(common.Address).Hex()
+	case hashType:
+		return v.Interface().(common.Hash).Hex()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return plainReflect(v.Elem())
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			bytes := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(bytes), v)
+			return hexutil.Encode(bytes)
+		}
+		ret := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			ret[i] = plainReflect(v.Index(i))
+		}
+		return ret
+	case reflect.Struct:
+		ret := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name := field.Name
+			// abi tuple keeps the original component name in json tag
+			if tag := field.Tag.Get("json"); tag != "" {
+				name = strings.Split(tag, ",")[0]
+			}
+			ret[name] = plainReflect(v.Field(i))
+		}
+		return ret
+	default:
+		return v.Interface()
+	}
+}
+
+// abiArgs converts the values from lua to the go values expected by abi arguments
+func abiArgs(args abi.Arguments, values []interface{}) ([]interface{}, error) {
+	if len(values) != len(args) {
+		return nil, fmt.Errorf("%v args are required, got %v", len(args), len(values))
+	}
+	ret := make([]interface{}, len(values))
+	for i, arg := range args {
+		name := arg.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		v, err := abiValue(arg.Type, values[i], name)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = v.Interface()
+	}
+	return ret, nil
+}
+
+// abiValue converts the value from lua to the go value of abi type, path locates the value in error.
+// Numbers can be lua numbers or decimal or hex strings, addresses and bytes are hex strings,
+// arrays are lua arrays and tuples are lua tables keyed by component name or lua arrays.
+func abiValue(t abi.Type, value interface{}, path string) (reflect.Value, error) {
+	typ := t.GetType()
+	if value != nil && reflect.TypeOf(value) == typ {
+		return reflect.ValueOf(value), nil
+	}
+	fail := func(reason string) (reflect.Value, error) {
+		return reflect.Value{}, fmt.Errorf("arg `%v`: cannot convert %v (%T) to %v: %v", path, value, value, t.String(), reason)
+	}
+
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n, err := parseInt(value)
+		if err != nil {
+			return fail(err.Error())
+		}
+		if t.T == abi.UintTy && n.Sign() < 0 {
+			return fail("negative unsigned integer")
+		}
+		bits := n.BitLen()
+		if t.T == abi.IntTy && n.Sign() < 0 {
+			bits = new(big.Int).Add(n, common.Big1).BitLen()
+		}
+		if t.T == abi.IntTy && bits >= t.Size || bits > t.Size {
+			return fail("overflow")
+		}
+		if typ == bigIntType {
+			return reflect.ValueOf(n), nil
+		}
+		v := reflect.New(typ).Elem()
+		if t.T == abi.IntTy {
+			v.SetInt(n.Int64())
+		} else {
+			v.SetUint(n.Uint64())
+		}
+		return v, nil
+	case abi.BoolTy:
+		switch b := value.(type) {
+		case bool:
+			return reflect.ValueOf(b), nil
+		case string:
+			parsed, err := strconv.ParseBool(b)
+			if err != nil {
+				return fail("invalid bool")
+			}
+			return reflect.ValueOf(parsed), nil
+		}
+		return fail("bool is required")
+	case abi.StringTy:
+		switch s := value.(type) {
+		case string:
+			return reflect.ValueOf(s), nil
+		case float64:
+			return reflect.ValueOf(strconv.FormatFloat(s, 'f', -1, 64)), nil
+		}
+		return fail("string is required")
+	case abi.AddressTy:
+		s, ok := value.(string)
+		if !ok || !common.IsHexAddress(s) {
+			return fail("hex address is required")
+		}
+		return reflect.ValueOf(common.HexToAddress(s)), nil
+	case abi.BytesTy:
+		b, err := parseBytes(value)
+		if err != nil {
+			return fail(err.Error())
+		}
+		return reflect.ValueOf(b), nil
+	case abi.FixedBytesTy, abi.FunctionTy:
+		b, err := parseBytes(value)
+		if err != nil {
+			return fail(err.Error())
+		}
+		v := reflect.New(typ).Elem()
+		if len(b) > v.Len() {
+			return fail(fmt.Sprintf("%v bytes is too long", len(b)))
+		}
+		// bytes are left aligned
+		reflect.Copy(v, reflect.ValueOf(b))
+		return v, nil
+	case abi.SliceTy, abi.ArrayTy:
+		list, ok := luaList(value)
+		if !ok {
+			return fail("array is required")
+		}
+		var v reflect.Value
+		if t.T == abi.ArrayTy {
+			if len(list) != t.Size {
+				return fail(fmt.Sprintf("%v elements are required, got %v", t.Size, len(list)))
+			}
+			v = reflect.New(typ).Elem()
+		} else {
+			v = reflect.MakeSlice(typ, len(list), len(list))
+		}
+		for i, elem := range list {
+			e, err := abiValue(*t.Elem, elem, fmt.Sprintf("%v[%v]", path, i))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Index(i).Set(e)
+		}
+		return v, nil
+	case abi.TupleTy:
+		fields, err := tupleFields(t, value)
+		if err != nil {
+			return fail(err.Error())
+		}
+		v := reflect.New(typ).Elem()
+		for i, elem := range t.TupleElems {
+			e, err := abiValue(*elem, fields[i], path+"."+t.TupleRawNames[i])
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Field(i).Set(e)
+		}
+		return v, nil
+	}
+	return fail("unsupported type")
+}
+
+// parseInt parses a lua number, a decimal or hex string or a go integer to big integer
+func parseInt(value interface{}) (*big.Int, error) {
+	switch v := value.(type) {
+	case float64:
+		if v != math.Trunc(v) || math.IsInf(v, 0) {
+			return nil, errors.New("integer is required")
+		}
+		n, _ := new(big.Float).SetFloat64(v).Int(nil)
+		return n, nil
+	case string:
+		s := strings.TrimSpace(v)
+		neg := strings.HasPrefix(s, "-")
+		s = strings.TrimPrefix(s, "-")
+		n, ok := new(big.Int).SetString(s, 0)
+		if !ok {
+			return nil, errors.New("invalid integer")
+		}
+		if neg {
+			n.Neg(n)
+		}
+		return n, nil
+	case *big.Int:
+		if v == nil {
+			return nil, errors.New("integer is required")
+		}
+		return new(big.Int).Set(v), nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(rv.Uint()), nil
+	}
+	return nil, errors.New("integer is required")
+}
+
+// parseUint parses a non-negative integer as parseInt
+func parseUint(value interface{}) (*big.Int, error) {
+	n, err := parseInt(value)
+	if err != nil {
+		return nil, err
+	}
+	if n.Sign() < 0 {
+		return nil, errors.New("non-negative integer is required")
+	}
+	return n, nil
+}
+
+// parseBytes parses a hex string with prefix 0x, other strings are used as raw bytes
+func parseBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		if has0xPrefix(v) {
+			b, err := hexutil.Decode(v)
+			if err != nil {
+				return nil, err
+			}
+			return b, nil
+		}
+		return []byte(v), nil
+	}
+	return nil, errors.New("bytes are required")
+}
+
+func has0xPrefix(s string) bool {
+	return len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X')
+}
+
+// luaList returns the elements of a lua array, which is a table keyed by 1 to n
+func luaList(value interface{}) ([]interface{}, bool) {
+	switch v := value.(type) {
+	case []interface{}:
+		return v, true
+	case map[interface{}]interface{}:
+		list := make([]interface{}, len(v))
+		for key, elem := range v {
+			i, ok := key.(float64)
+			if !ok || i != math.Trunc(i) || i < 1 || int(i) > len(v) {
+				return nil, false
+			}
+			list[int(i)-1] = elem
+		}
+		return list, true
+	}
+	rv := reflect.ValueOf(value)
+	if value != nil && (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) {
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			list[i] = rv.Index(i).Interface()
+		}
+		return list, true
+	}
+	return nil, false
+}
+
+// tupleFields returns the components of tuple from a lua table keyed by component name or a lua array
+func tupleFields(t abi.Type, value interface{}) ([]interface{}, error) {
+	if list, ok := luaList(value); ok && len(list) > 0 {
+		if len(list) != len(t.TupleElems) {
+			return nil, fmt.Errorf("%v components are required, got %v", len(t.TupleElems), len(list))
+		}
+		return list, nil
+	}
+	var table map[string]interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		table = v
+	case map[interface{}]interface{}:
+		table = make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid component name %v", key)
+			}
+			table[name] = elem
+		}
+	default:
+		return nil, errors.New("table is required")
+	}
+	fields := make([]interface{}, len(t.TupleElems))
+	for i, name := range t.TupleRawNames {
+		elem, ok := table[name]
+		if !ok {
+			return nil, fmt.Errorf("component `%v` is missing", name)
+		}
+		fields[i] = elem
+	}
+	if len(table) != len(fields) {
+		return nil, fmt.Errorf("%v components are required, got %v", len(fields), len(table))
+	}
+	return fields, nil
+}
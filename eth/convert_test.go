@@ -0,0 +1,100 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+const convertABI = `[{"name":"submit","type":"function","stateMutability":"nonpayable","inputs":[
+	{"name":"to","type":"address"},
+	{"name":"amount","type":"uint256"},
+	{"name":"delta","type":"int8"},
+	{"name":"id","type":"bytes32"},
+	{"name":"ids","type":"uint64[2]"},
+	{"name":"orders","type":"tuple[]","components":[
+		{"name":"maker","type":"address"},
+		{"name":"price","type":"uint128"},
+		{"name":"data","type":"bytes"}]},
+	{"name":"memo","type":"string"},
+	{"name":"flag","type":"bool"}],"outputs":[]}]`
+
+func TestABIArgs(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(convertABI))
+	assert.NoError(t, err)
+	inputs := parsed.Methods["submit"].Inputs
+	maker := "0x74d366e0649a91395bb122c005917644382b9452"
+
+	// values from lua
+	values := []interface{}{
+		maker,
+		"0xde0b6b3a7640000",
+		float64(-128),
+		"0x1234",
+		map[interface{}]interface{}{float64(1): float64(7), float64(2): "8"},
+		map[interface{}]interface{}{
+			float64(1): map[interface{}]interface{}{"maker": maker, "price": "1000000000000000000000", "data": "0xff"},
+			float64(2): []interface{}{maker, float64(1), "raw"},
+		},
+		"hello",
+		true,
+	}
+	args, err := abiArgs(inputs, values)
+	assert.NoError(t, err)
+	assert.Equal(t, common.HexToAddress(maker), args[0])
+	assert.Equal(t, big.NewInt(1000000000000000000), args[1])
+	assert.Equal(t, int8(-128), args[2])
+	assert.Equal(t, [32]byte{0x12, 0x34}, args[3])
+	assert.Equal(t, [2]uint64{7, 8}, args[4])
+	assert.Equal(t, "hello", args[6])
+	_, err = parsed.Pack("submit", args...)
+	assert.NoError(t, err)
+
+	orders := plainValue(args[5]).([]interface{})
+	assert.Len(t, orders, 2)
+	assert.Equal(t, "1000000000000000000000", orders[0].(map[string]interface{})["price"])
+	assert.Equal(t, "0x726177", orders[1].(map[string]interface{})["data"])
+
+	// errors locate the value
+	bad := append([]interface{}{}, values...)
+	bad[2] = float64(128)
+	_, err = abiArgs(inputs, bad)
+	assert.EqualError(t, err, "arg `delta`: cannot convert 128 (float64) to int8: overflow")
+	bad[2] = float64(1)
+	bad[5] = map[interface{}]interface{}{float64(1): map[interface{}]interface{}{"maker": "alice", "price": float64(1), "data": ""}}
+	_, err = abiArgs(inputs, bad)
+	assert.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "arg `orders[0].maker`"))
+	bad[5] = []interface{}{}
+	bad[4] = []interface{}{float64(1)}
+	_, err = abiArgs(inputs, bad)
+	assert.Error(t, err)
+	bad[4] = []interface{}{float64(1), float64(2)}
+	bad[1] = float64(-1)
+	_, err = abiArgs(inputs, bad)
+	assert.Error(t, err)
+	_, err = abiArgs(inputs, values[:2])
+	assert.EqualError(t, err, "8 args are required, got 2")
+}
+
+func TestParseUint(t *testing.T) {
+	n, err := parseUint(float64(16))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(16), n.Int64())
+	n, err = parseUint("0x10")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(16), n.Int64())
+	n, err = parseUint(uint64(16))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(16), n.Int64())
+	_, err = parseUint(float64(-1))
+	assert.Error(t, err)
+	_, err = parseUint("-0x10")
+	assert.Error(t, err)
+	_, err = parseUint("ten")
+	assert.Error(t, err)
+}
@@ -0,0 +1,323 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide the corpus of transactions signed before benchmark
+ * @file corpus.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	fcom "github.com/hyperbench/hyperbench-common/common"
+)
+
+// corpusVM is replaced by the index of vm in corpus file name
+const corpusVM = "{vm}"
+
+// corpusTx is a signed transaction in corpus
+type corpusTx struct {
+	Label string `json:"label"`
+	// Raw is the encoded transaction in hex
+	Raw    string `json:"raw"`
+	hash   common.Hash
+	sender common.Address
+	nonce  uint64
+	gas    uint64
+	data   []byte
+}
+
+// corpus contains the signed transactions of vm keyed by label,
+// they are sent in order by invoking the functions or transferring
+type corpus struct {
+	txs map[string][]*corpusTx
+}
+
+func newCorpus() *corpus {
+	return &corpus{txs: make(map[string][]*corpusTx)}
+}
+
+// add adds the transaction to the end of its label
+func (c *corpus) add(tx *corpusTx) {
+	c.txs[tx.Label] = append(c.txs[tx.Label], tx)
+}
+
+// pop returns the next transaction of label, nil if there is no transaction left
+func (c *corpus) pop(label string) *corpusTx {
+	if c == nil || len(c.txs[label]) == 0 {
+		return nil
+	}
+	tx := c.txs[label][0]
+	c.txs[label] = c.txs[label][1:]
+	return tx
+}
+
+// corpusOption is the option to build corpus
+type corpusOption struct {
+	count int
+	// label is the function to invoke, or BuiltinTransferLabel to transfer
+	label string
+	args  []interface{}
+	to    string
+	value *big.Int
+	file  string
+}
+
+// parseCorpusOption parses the option `corpus`
+func parseCorpusOption(value interface{}) (*corpusOption, error) {
+	table, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("option `corpus` type error: " + reflect.TypeOf(value).Name())
+	}
+	op := &corpusOption{label: fcom.BuiltinTransferLabel, value: big.NewInt(0)}
+	for k, v := range table {
+		key, _ := k.(string)
+		var ok bool
+		switch key {
+		case "count":
+			var count float64
+			count, ok = v.(float64)
+			op.count = int(count)
+		case "func":
+			op.label, ok = v.(string)
+		case "args":
+			op.args, ok = luaList(v)
+		case "to":
+			op.to, ok = v.(string)
+			ok = ok && common.IsHexAddress(op.to)
+		case "value":
+			var err error
+			op.value, err = parseUint(v)
+			ok = err == nil
+		case "file":
+			op.file, ok = v.(string)
+		default:
+			return nil, fmt.Errorf("option `corpus` error: unknown key %v", k)
+		}
+		if !ok {
+			return nil, fmt.Errorf("option `corpus` error: invalid %v: %v", k, v)
+		}
+	}
+	if op.count <= 0 && op.file == "" {
+		return nil, errors.New("option `corpus` error: count or file is required")
+	}
+	return op, nil
+}
+
+// prepareCorpus loads the corpus from file if it exists,
+// otherwise builds the corpus and saves it to file if file is given
+func (e *ETH) prepareCorpus(op *corpusOption) error {
+	file := strings.Replace(op.file, corpusVM, strconv.FormatUint(e.vmIndex, 10), -1)
+	if file != "" {
+		if _, err := os.Stat(file); err == nil {
+			return e.loadCorpus(file)
+		}
+	}
+	if e.corpus == nil {
+		e.corpus = newCorpus()
+	}
+	begin := time.Now()
+	var txs []*corpusTx
+	for i := 0; i < op.count; i++ {
+		tx, err := e.buildCorpusTx(op)
+		if err != nil {
+			return err
+		}
+		e.corpus.add(tx)
+		txs = append(txs, tx)
+	}
+	e.Logger.Noticef("%v transactions of %v are signed in %v", len(txs), op.label, time.Since(begin))
+	if file == "" {
+		return nil
+	}
+	return saveCorpus(file, txs)
+}
+
+// buildCorpusTx signs a transaction with the nonce allocated now,
+// the nonce is reported to nonce manager when the transaction is sent
+func (e *ETH) buildCorpusTx(op *corpusOption) (*corpusTx, error) {
+	s, err := e.invokeSender()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		to    *common.Address
+		value = op.value
+		data  []byte
+		gas   uint64
+		list  types.AccessList
+	)
+	if op.label == fcom.BuiltinTransferLabel {
+		receiver := s.address
+		if op.to != "" {
+			receiver = common.HexToAddress(op.to)
+		}
+		to = &receiver
+		if e.token != nil {
+			data = tokenTransfer(receiver, value)
+			to, value = e.token, big.NewInt(0)
+			gas = e.tokenGasLimit(s.address, data)
+		} else {
+			gas = e.transferGasLimit(s.address, to, value, nil)
+		}
+	} else {
+		inv, err := e.packInvoke(op.label, op.args)
+		if err != nil {
+			return nil, err
+		}
+		to, data, list = inv.to, inv.data, inv.accessList
+		if gas, err = e.invocationGasLimit(s.address, op.label, op.args, inv); err != nil {
+			return nil, err
+		}
+	}
+
+	nonce, err := e.nonces.next(s.address)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := e.signListTx(s.key, nonce, to, value, gas, data, list)
+	if err != nil {
+		e.nonces.commit(s.address, nonce, err)
+		return nil, err
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		e.nonces.commit(s.address, nonce, err)
+		return nil, err
+	}
+	return &corpusTx{
+		Label:  op.label,
+		Raw:    hexutil.Encode(raw),
+		hash:   tx.Hash(),
+		sender: s.address,
+		nonce:  nonce,
+		gas:    gas,
+		data:   data,
+	}, nil
+}
+
+// saveCorpus saves transactions to file, one json per line
+func saveCorpus(file string, txs []*corpusTx) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	for _, tx := range txs {
+		if err = encoder.Encode(tx); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// loadCorpus loads the transactions from file, the nonces of senders are moved after them
+func (e *ETH) loadCorpus(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if e.corpus == nil {
+		e.corpus = newCorpus()
+	}
+	next := make(map[common.Address]uint64)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	count := 0
+	for scanner.Scan() {
+		ctx := &corpusTx{}
+		if err = json.Unmarshal(scanner.Bytes(), ctx); err != nil {
+			return err
+		}
+		raw, err := hexutil.Decode(ctx.Raw)
+		if err != nil {
+			return err
+		}
+		tx := new(types.Transaction)
+		if err = tx.UnmarshalBinary(raw); err != nil {
+			return err
+		}
+		if ctx.sender, err = types.Sender(e.signer, tx); err != nil {
+			return err
+		}
+		ctx.hash, ctx.nonce, ctx.gas, ctx.data = tx.Hash(), tx.Nonce(), tx.Gas(), tx.Data()
+		if ctx.nonce+1 > next[ctx.sender] {
+			next[ctx.sender] = ctx.nonce + 1
+		}
+		e.corpus.add(ctx)
+		count++
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+	for sender, nonce := range next {
+		if err = e.nonces.advance(sender, nonce); err != nil {
+			return err
+		}
+	}
+	e.Logger.Noticef("%v transactions are loaded from %v", count, file)
+	return nil
+}
+
+// sendCorpusTx sends the raw transaction of corpus
+func (e *ETH) sendCorpusTx(tx *corpusTx, buildTime int64) *fcom.Result {
+	var url string
+	ep, err := e.endpoints.call(func(ep *endpoint) error {
+		return ep.rpcClient.CallContext(context.Background(), nil, "eth_sendRawTransaction", tx.Raw)
+	})
+	if ep != nil {
+		url = ep.url
+	}
+	sendTime := time.Now().UnixNano()
+	e.nonces.commit(tx.sender, tx.nonce, err)
+	if err != nil {
+		e.Logger.Errorf("send transaction of corpus error: %v", err)
+		return &fcom.Result{
+			Label:     tx.Label,
+			UID:       fcom.InvalidUID,
+			Ret:       endpointRet(url),
+			Status:    fcom.Failure,
+			BuildTime: buildTime,
+			SendTime:  sendTime,
+		}
+	}
+	return &fcom.Result{
+		Label:     tx.Label,
+		UID:       tx.hash.String(),
+		Ret:       withGasLimit(endpointRet(url, tx.data), tx.gas),
+		Status:    fcom.Success,
+		BuildTime: buildTime,
+		SendTime:  sendTime,
+	}
+}
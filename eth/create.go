@@ -0,0 +1,95 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide the contract creation invoked as a benchmarked operation
+ * @file create.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// deployLabel is the reserved function invoking the creation of contract with constructor args,
+	// `Name.__deploy` creates the contract named Name
+	deployLabel = "__deploy"
+	// createdKey and codeSizeKey are the keys of the created contract in the result of confirm
+	createdKey  = "contractAddress"
+	codeSizeKey = "codeSize"
+)
+
+// invocation is the transaction input of invoking function
+type invocation struct {
+	// contract is the contract invoked or created, to is nil if it is created
+	contract *Contract
+	to       *common.Address
+	sig      string
+	data     []byte
+	// accessList is the access list of transaction, nil if it is not used
+	accessList types.AccessList
+}
+
+// packInvoke packs the input of function with args, the function is routed by contract name
+// and deployLabel creates a new instance of contract from its bin
+func (e *ETH) packInvoke(fn string, fnArgs []interface{}) (*invocation, error) {
+	c, method, err := e.route(fn)
+	if err != nil {
+		return nil, err
+	}
+	args, err := abiArgs(method.Inputs, fnArgs)
+	if err != nil {
+		return nil, fmt.Errorf("invoke %v: %v", fn, err)
+	}
+	data, err := c.parsedAbi.Pack(method.Name, args...)
+	if err != nil {
+		return nil, err
+	}
+	inv := &invocation{contract: c, to: &c.Address, sig: routeSig(fn, method), data: data}
+	if method.Type == abi.Constructor {
+		if c.BIN == "" {
+			return nil, errors.New("no bin to create contract: " + c.Name)
+		}
+		if placeholders := scanPlaceholders(c.BIN); len(placeholders) > 0 {
+			return nil, fmt.Errorf("unlinked library %v to create contract: %v", placeholders[0], c.Name)
+		}
+		inv.to, inv.data = nil, append(common.FromHex(c.BIN), data...)
+	}
+	return inv, nil
+}
+
+// createdInfo records the address and the code size of contract created by receipt
+func (e *ETH) createdInfo(r *receipt, info map[string]interface{}) {
+	info[createdKey] = r.ContractAddress.Hex()
+	var code []byte
+	_, err := e.endpoints.call(func(ep *endpoint) (err error) {
+		code, err = ep.ethClient.CodeAt(context.Background(), r.ContractAddress, r.BlockNumber)
+		return err
+	})
+	if err != nil {
+		e.Logger.Errorf("query code of %v failed: %v", r.ContractAddress.Hex(), err)
+		return
+	}
+	info[codeSizeKey] = len(code)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	fcom "github.com/hyperbench/hyperbench-common/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvokeDeploy(t *testing.T) {
+	client, stop := newSimulatedClient(t, storageABI, storageBIN)
+	defer stop()
+	assert.NoError(t, client.DeployContract())
+
+	// every invocation creates a new instance of contract
+	var created []string
+	for i := 0; i < 2; i++ {
+		res := client.Invoke(fcom.Invoke{Func: deployLabel})
+		assert.Equal(t, fcom.Success, res.Status)
+		res = client.Confirm(res)
+		assert.Equal(t, fcom.Confirm, res.Status)
+		info := res.Ret[0].(map[string]interface{})
+		address := info[createdKey].(string)
+		assert.NotEqual(t, client.contract.Address.Hex(), address)
+		code, err := client.ethClient().CodeAt(context.Background(), common.HexToAddress(address), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, len(code), info[codeSizeKey])
+		assert.True(t, len(code) > 0)
+		created = append(created, address)
+	}
+	assert.NotEqual(t, created[0], created[1])
+
+	// the contract is routed by name and the constructor args are checked
+	res := client.Invoke(fcom.Invoke{Func: "contract." + deployLabel})
+	assert.Equal(t, fcom.Confirm, client.Confirm(res).Status)
+	res = client.Invoke(fcom.Invoke{Func: deployLabel, Args: []interface{}{float64(1)}})
+	assert.Equal(t, fcom.Failure, res.Status)
+
+	// the invocation of method has no created contract
+	res = client.Confirm(client.Invoke(fcom.Invoke{Func: "set", Args: []interface{}{float64(1)}}))
+	assert.Equal(t, fcom.Confirm, res.Status)
+	assert.NotContains(t, res.Ret[0], createdKey)
+
+	// the bin with unlinked library is not created
+	client.contract.BIN = "6000" + hashPlaceholder("lib.sol:Lib") + "00"
+	res = client.Invoke(fcom.Invoke{Func: deployLabel})
+	assert.Equal(t, fcom.Failure, res.Status)
+}
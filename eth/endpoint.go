@@ -0,0 +1,286 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide the binding of rpc endpoints and the failover among them
+ * @file endpoint.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	fcom "github.com/hyperbench/hyperbench-common/common"
+	"github.com/op/go-logging"
+)
+
+const (
+	// endpointKey is the key of endpoint used by transaction in result
+	endpointKey = "endpoint"
+	// defaultEndpointRetry is how long a failed endpoint is skipped
+	defaultEndpointRetry = 10 * time.Second
+)
+
+// errNoEndpoint is returned when no endpoint is configured
+var errNoEndpoint = errors.New("no rpc endpoint")
+
+// endpoint is a rpc endpoint of node
+type endpoint struct {
+	url       string
+	rpcClient *rpc.Client
+	ethClient *ethclient.Client
+	// downUntil is the time before which the endpoint is not used after failure
+	downUntil time.Time
+}
+
+// endpointPool binds vm to an endpoint and fails over to the next healthy one
+type endpointPool struct {
+	logger *logging.Logger
+	retry  time.Duration
+	dial   func(url string) (*rpc.Client, error)
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+	// bound is the endpoint bound to vm, it is used again once it recovers
+	bound   int
+	current int
+}
+
+// newEndpointPool creates the endpoint pool of urls, the vm with index `i` is bound to the endpoint `i % len(urls)`
+func newEndpointPool(urls []string, vmIndex uint64, retry time.Duration, logger *logging.Logger) (*endpointPool, error) {
+	if len(urls) == 0 {
+		return nil, errNoEndpoint
+	}
+	if retry <= 0 {
+		retry = defaultEndpointRetry
+	}
+	p := &endpointPool{
+		logger: logger,
+		retry:  retry,
+		dial:   dialEndpoint,
+		bound:  int(vmIndex % uint64(len(urls))),
+	}
+	p.current = p.bound
+	for _, url := range urls {
+		p.endpoints = append(p.endpoints, &endpoint{url: url})
+	}
+	// the bound endpoint is dialed first, others are dialed on failover
+	if _, err := p.get(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// get returns the current endpoint, an unreachable endpoint is skipped
+func (p *endpointPool) get() (*endpoint, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.current != p.bound && !p.endpoints[p.bound].downUntil.After(time.Now()) {
+		p.current = p.bound
+	}
+	var lastErr error
+	for i := 0; i < len(p.endpoints); i++ {
+		ep := p.endpoints[p.current]
+		if ep.rpcClient != nil {
+			return ep, nil
+		}
+		client, err := p.dial(ep.url)
+		if err == nil {
+			ep.rpcClient, ep.ethClient = client, ethclient.NewClient(client)
+			return ep, nil
+		}
+		p.logger.Errorf("dial %v failed: %v", ep.url, err)
+		lastErr = err
+		ep.downUntil = time.Now().Add(p.retry)
+		p.current = p.next()
+	}
+	return nil, lastErr
+}
+
+// each calls fn with every endpoint and returns the first error with the url of endpoint,
+// the endpoint unreachable by dialing or by fn is skipped and left to failover
+func (p *endpointPool) each(fn func(ep *endpoint) error) error {
+	for _, ep := range p.endpoints {
+		p.mu.Lock()
+		dialed := ep.rpcClient != nil
+		p.mu.Unlock()
+		if !dialed {
+			client, err := p.dial(ep.url)
+			if err != nil {
+				p.logger.Errorf("dial %v failed: %v", ep.url, err)
+				continue
+			}
+			p.mu.Lock()
+			if ep.rpcClient == nil {
+				ep.rpcClient, ep.ethClient = client, ethclient.NewClient(client)
+			} else {
+				client.Close()
+			}
+			p.mu.Unlock()
+		}
+		err := fn(ep)
+		if isEndpointError(err) {
+			p.logger.Errorf("endpoint %v is unreachable: %v", ep.url, err)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%v: %v", ep.url, err)
+		}
+	}
+	return nil
+}
+
+// next returns the index of next healthy endpoint after current,
+// or the one recovering earliest if all endpoints are down
+func (p *endpointPool) next() int {
+	now := time.Now()
+	earliest := p.current
+	for i := 1; i <= len(p.endpoints); i++ {
+		idx := (p.current + i) % len(p.endpoints)
+		ep := p.endpoints[idx]
+		if !ep.downUntil.After(now) {
+			return idx
+		}
+		if ep.downUntil.Before(p.endpoints[earliest].downUntil) {
+			earliest = idx
+		}
+	}
+	return earliest
+}
+
+// failover marks the endpoint failed and moves to the next healthy endpoint
+func (p *endpointPool) failover(failed *endpoint, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.endpoints[p.current] != failed {
+		// already moved by others
+		return
+	}
+	failed.downUntil = time.Now().Add(p.retry)
+	p.current = p.next()
+	p.logger.Noticef("endpoint %v failed, switch to %v: %v", failed.url, p.endpoints[p.current].url, err)
+}
+
+// call calls fn with the current endpoint, it is retried on the next endpoint if the endpoint failed.
+// The endpoint used lastly is returned.
+func (p *endpointPool) call(fn func(ep *endpoint) error) (*endpoint, error) {
+	var (
+		ep  *endpoint
+		err error
+	)
+	for i := 0; i < len(p.endpoints); i++ {
+		ep, err = p.get()
+		if err != nil {
+			return nil, err
+		}
+		err = fn(ep)
+		if !isEndpointError(err) {
+			return ep, err
+		}
+		p.failover(ep, err)
+	}
+	return ep, err
+}
+
+// PendingNonceAt returns the pending nonce of account with failover
+func (p *endpointPool) PendingNonceAt(ctx context.Context, account common.Address) (nonce uint64, err error) {
+	_, err = p.call(func(ep *endpoint) error {
+		nonce, err = ep.ethClient.PendingNonceAt(ctx, account)
+		return err
+	})
+	return nonce, err
+}
+
+// isEndpointError returns whether the error is caused by endpoint instead of the request,
+// the errors returned by node in json-rpc response are not endpoint errors
+func isEndpointError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if _, ok := err.(rpc.Error); ok {
+		return false
+	}
+	var netErr net.Error
+	var httpErr rpc.HTTPError
+	switch {
+	case errors.As(err, &httpErr), errors.As(err, &netErr):
+		return true
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, rpc.ErrClientQuit):
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "connection refused") || strings.Contains(msg, "connection reset") || strings.Contains(msg, "broken pipe")
+}
+
+// clients returns the clients of current endpoint, or the clients dialed before if no endpoint is reachable,
+// the request will fail with connection error and fail over
+func (p *endpointPool) clients() (*rpc.Client, *ethclient.Client) {
+	if ep, err := p.get(); err == nil {
+		return ep.rpcClient, ep.ethClient
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, ep := range p.endpoints {
+		if ep.rpcClient != nil {
+			return ep.rpcClient, ep.ethClient
+		}
+	}
+	return nil, nil
+}
+
+// ethClient returns the eth client of current endpoint, the requests by it are not failed over,
+// so it is used by setup, status and queries only and the requests of test go through endpoints.call
+func (e *ETH) ethClient() *ethclient.Client {
+	_, client := e.endpoints.clients()
+	return client
+}
+
+// rpcClient returns the rpc client of current endpoint, the requests by it are not failed over as ethClient
+func (e *ETH) rpcClient() *rpc.Client {
+	client, _ := e.endpoints.clients()
+	return client
+}
+
+// endpointRet returns the values of result with the endpoint recorded
+func endpointRet(endpoint string, values ...interface{}) []interface{} {
+	if endpoint == "" {
+		return append([]interface{}{}, values...)
+	}
+	return append(values, map[string]interface{}{endpointKey: endpoint})
+}
+
+// resultEndpoint returns the endpoint recorded in result
+func resultEndpoint(result *fcom.Result) string {
+	for _, value := range result.Ret {
+		if m, ok := value.(map[string]interface{}); ok {
+			if endpoint, ok := m[endpointKey].(string); ok {
+				return endpoint
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	fcom "github.com/hyperbench/hyperbench-common/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeNonceService returns a fixed nonce to identify the node
+type fakeNonceService struct {
+	nonce uint64
+}
+
+func (s *fakeNonceService) GetTransactionCount(account common.Address, block string) hexutil.Uint64 {
+	return hexutil.Uint64(s.nonce)
+}
+
+func newFakeNode(t *testing.T, nonce uint64) *httptest.Server {
+	server := rpc.NewServer()
+	assert.NoError(t, server.RegisterName("eth", &fakeNonceService{nonce: nonce}))
+	return httptest.NewServer(server)
+}
+
+func TestEndpointPool(t *testing.T) {
+	nodeA, nodeB := newFakeNode(t, 1), newFakeNode(t, 2)
+	defer nodeA.Close()
+	account := common.HexToAddress("0x74d366e0649a91395bb122c005917644382b9452")
+
+	// vm 3 is bound to the second node
+	p, err := newEndpointPool([]string{nodeA.URL, nodeB.URL}, 3, 0, fcom.GetLogger("eth"))
+	assert.NoError(t, err)
+	nonce, err := p.PendingNonceAt(context.Background(), account)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), nonce)
+
+	// fail over to the first node
+	nodeB.Close()
+	ep, err := p.call(func(ep *endpoint) error {
+		nonce, err = ep.ethClient.PendingNonceAt(context.Background(), account)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), nonce)
+	assert.Equal(t, nodeA.URL, ep.url)
+	assert.Equal(t, 0, p.current)
+
+	// errors of request are not retried
+	calls := 0
+	_, err = p.call(func(ep *endpoint) error {
+		calls++
+		return errors.New("nonce too low")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+
+	_, err = newEndpointPool(nil, 0, 0, fcom.GetLogger("eth"))
+	assert.Equal(t, errNoEndpoint, err)
+}
+
+func TestEndpointRet(t *testing.T) {
+	result := &fcom.Result{Ret: endpointRet("http://127.0.0.1:8545", []byte{1})}
+	assert.Len(t, result.Ret, 2)
+	assert.Equal(t, "http://127.0.0.1:8545", resultEndpoint(result))
+	assert.Equal(t, []interface{}{}, endpointRet(""))
+	assert.Equal(t, "", resultEndpoint(&fcom.Result{}))
+}
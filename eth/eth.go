@@ -28,12 +28,13 @@ import (
 	"encoding/json"
 	"errors"
 	"os"
-	"path"
+	"path/filepath"
 	"reflect"
 	"strings"
 
 	"io/ioutil"
 	"math/big"
+	"math/rand"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -42,7 +43,6 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/hyperbench/hyperbench-common/base"
 	fcom "github.com/hyperbench/hyperbench-common/common"
 
@@ -50,44 +50,103 @@ import (
 	"github.com/spf13/viper"
 )
 
-const gasLimit = 300000
-
 //Contract contains the abi and bin files of contract
 type Contract struct {
-	ABI             string
-	BIN             string
-	parsedAbi       abi.ABI
-	contractAddress common.Address
+	// Name is the name of contract, `Name.method` is routed to it
+	Name      string
+	ABI       string
+	BIN       string
+	parsedAbi abi.ABI
+	// Address is the address of contract, it is shipped to workers in context
+	Address common.Address
+	// artifact is the compiled contract, artifacts are all contracts loaded with it
+	artifact  *artifact
+	artifacts []*artifact
 }
 type option struct {
-	gas    *big.Int
-	setGas bool
-	noSend bool
+	gas            *big.Int
+	setGas         bool
+	noSend         bool
+	txType         string
+	maxFee         *big.Int
+	maxPriorityFee *big.Int
+	// confirmTimeout is how long to wait for the receipt
+	confirmTimeout time.Duration
+	// confirmInterval is how often to poll the receipt
+	confirmInterval time.Duration
+	// confirmations is the number of blocks on top of the inclusion block before confirmed
+	confirmations uint64
+	// events maps function name to the event it should emit, empty name for all functions
+	events map[string]string
+	// sender is the strategy to choose sender of invoking contract
+	sender string
+	// account is the alias of the sender used by fixed strategy
+	account string
+	// precheck is whether to call the contract before sending the transaction
+	precheck bool
+	// gasLimits are the gas limits keyed by function name or signature, empty name for all functions
+	gasLimits map[string]uint64
+	// trace is how to trace the confirmed transactions, nil if they are not traced
+	trace *traceOption
+	// accessList is how the access lists of invoking are cached, empty if they are not used
+	accessList string
 }
 
 //ETH the client of eth
 type ETH struct {
 	*base.BlockchainBase
-	ethClient  *ethclient.Client
+	endpoints  *endpointPool
+	fee        *feeOracle
+	signer     types.Signer
+	london     bool
+	headers    *headerCache
+	watcher    *headWatcher
 	privateKey *ecdsa.PrivateKey
 	publicKey  *ecdsa.PublicKey
 	auth       *bind.TransactOpts
 	contract   *Contract
-	Accounts   map[string]*ecdsa.PrivateKey
-	chainID    *big.Int
-	gasPrice   *big.Int
-	round      uint64
-	nonce      uint64
-	engineCap  uint64
-	workerNum  uint64
-	wkIdx      uint64
-	vmIdx      uint64
+	// contracts are all contracts deployed keyed by name, contract is the default one of them
+	contracts map[string]*Contract
+	Accounts  map[string]*ecdsa.PrivateKey
+	chainID   *big.Int
+	gasPrice  *big.Int
+	gas       *gasEstimator
+	nonces    *nonceManager
+	senders   *senderPool
+	// vmIndex is the index of vm among all vms of workers, vmCount is the number of them
+	vmIndex uint64
+	vmCount uint64
+	// instants are the accounts created by master for workers
+	instants map[string]*ecdsa.PrivateKey
+	// corpus are the transactions signed before benchmark
+	corpus *corpus
+	// accessLists are the access lists created for invoking
+	accessLists *accessListCache
+	// token is the ERC-20 token transferred instead of ether,
+	// tokenFund is the amount funded to accounts by master, it is nil after funded
+	token     *common.Address
+	tokenFund *big.Int
+	// statuses are the status of node keyed by the timestamp of chain status
+	statuses map[int64]*nodeStatus
+	// reorgs tracks the blocks removed by reorg on master, it is started by the first chain status
+	reorgs *reorgTracker
+	// random samples the transactions to trace, it is seeded by time and vm index so that vms sample differently
+	random     *rand.Rand
+	mainSender *sender
 	op         option
 }
 
 //Msg contains message of context
 type Msg struct {
 	Contract *Contract
+	// Contracts is the address book of all contracts if there are several
+	Contracts map[string]*Contract `json:"Contracts,omitempty"`
+	// Nonces maps account address to its first nonce for workers
+	Nonces map[string]uint64 `json:"Nonces,omitempty"`
+	// Instants maps the alias of instant account to its private key in hex
+	Instants map[string]string `json:"Instants,omitempty"`
+	// Token is the address of ERC-20 token in token mode
+	Token *common.Address `json:"Token,omitempty"`
 }
 
 var (
@@ -101,36 +160,66 @@ func init() {
 	log := fcom.GetLogger("eth")
 	configPath := viper.GetString(fcom.ClientConfigPath)
 	options := viper.GetStringMap(fcom.ClientOptionPath)
+	accounts = make(map[string]*ecdsa.PrivateKey)
+	var keys []accountKey
+
 	files, err := ioutil.ReadDir(configPath + "/keystore")
-	if err != nil {
+	if err != nil && options["keyfile"] == nil && options["mnemonic"] == nil {
 		log.Errorf("access keystore failed:%v", err)
 	}
-	accounts = make(map[string]*ecdsa.PrivateKey)
-	for i, file := range files {
+	for _, file := range files {
 		fileName := file.Name()
 		account := fileName[strings.LastIndex(fileName, "-")+1:]
 		privKey, _, err := KeystoreToPrivateKey(configPath+"/keystore/"+fileName, cast.ToString(options["keypassword"]))
 		if err != nil {
 			log.Errorf("access account file failed: %v", err)
+			continue
 		}
 
 		privateKey, err := crypto.HexToECDSA(privKey)
 		if err != nil {
 			log.Errorf("privatekey encode failed %v ", err)
+			continue
 		}
-		publicKey := privateKey.Public()
-		publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-		if !ok {
-			log.Errorf("cannot assert type: publicKey is not of type *ecdsa.PublicKey")
+		keys = append(keys, accountKey{alias: account, key: privateKey})
+	}
+
+	// plain private keys in hex
+	if keyFile := cast.ToString(options["keyfile"]); keyFile != "" {
+		if !filepath.IsAbs(keyFile) {
+			keyFile = filepath.Join(configPath, keyFile)
+		}
+		loaded, err := loadKeyFile(keyFile)
+		if err != nil {
+			log.Errorf("load key file failed: %v", err)
+		}
+		keys = append(keys, loaded...)
+	}
+
+	// accounts derived from mnemonic by bip-44 path
+	if mnemonic := cast.ToString(options["mnemonic"]); mnemonic != "" {
+		count := cast.ToInt(options["hdcount"])
+		if count <= 0 {
+			count = 1
+		}
+		derived, err := deriveAccounts(mnemonic, cast.ToString(options["passphrase"]), cast.ToString(options["hdpath"]), count)
+		if err != nil {
+			log.Errorf("derive accounts failed: %v", err)
 		}
-		accounts[account] = privateKey
+		keys = append(keys, derived...)
+	}
+
+	for i, k := range keys {
+		accounts[k.alias] = k.key
 		if i == 0 {
-			PublicK = publicKeyECDSA
-			PrivateK = privateKey
+			PublicK = &k.key.PublicKey
+			PrivateK = k.key
 		}
 	}
 
-	fromAddress = crypto.PubkeyToAddress(*PublicK)
+	if PublicK != nil {
+		fromAddress = crypto.PubkeyToAddress(*PublicK)
+	}
 }
 
 // New use given blockchainBase create ETH.
@@ -142,17 +231,55 @@ func New(blockchainBase *base.BlockchainBase) (client interface{}, err error) {
 		return nil, err
 	}
 	viper.MergeConfig(ethConfig)
-	ethClient, err := ethclient.Dial(viper.GetString("rpc.node") + ":" + viper.GetString("rpc.port"))
-	if err != nil {
-		log.Errorf("ethClient initiate fialed: %v", err)
-		return nil, err
+	// nonces of shared accounts are partitioned among all vms of workers,
+	// and vms are distributed across nodes by the same index
+	engineCap := viper.GetUint64(fcom.EngineCapPath)
+	workerNum := uint64(len(viper.GetStringSlice(fcom.EngineURLsPath)))
+	if workerNum == 0 {
+		workerNum = 1
 	}
-
-	nonce, err := ethClient.PendingNonceAt(context.Background(), fromAddress)
+	var offset uint64
+	if blockchainBase.WorkerID >= 0 && blockchainBase.VmID >= 0 {
+		offset = uint64(blockchainBase.WorkerID)*(engineCap/workerNum) + uint64(blockchainBase.VmID)
+	}
+	urls := viper.GetStringSlice("rpc.nodes")
+	if len(urls) == 0 {
+		urls = []string{viper.GetString("rpc.node") + ":" + viper.GetString("rpc.port")}
+	}
+	ws := viper.GetString("rpc.ws")
+	switch backend := viper.GetString("rpc.backend"); backend {
+	case "":
+	case backendSimulated:
+		// the simulated chain in process is used as the only node
+		config := simulatedConfig{
+			txs:      viper.GetInt("simulated.txs"),
+			interval: viper.GetDuration("simulated.interval"),
+			flush:    viper.GetDuration("simulated.flush"),
+			gasLimit: viper.GetUint64("simulated.gaslimit"),
+		}
+		if balance := viper.GetString("simulated.balance"); balance != "" {
+			config.balance, err = parseUint(balance)
+			if err != nil {
+				log.Errorf("parse balance of simulated chain failed: %v", err)
+				return nil, err
+			}
+		}
+		if _, err = getSimulatedChain(accounts, config, log); err != nil {
+			log.Errorf("start simulated chain failed: %v", err)
+			return nil, err
+		}
+		urls, ws = []string{simulatedURL}, simulatedURL
+	default:
+		log.Errorf("unknown rpc backend: %v", backend)
+		return nil, errors.New("unknown rpc backend: " + backend)
+	}
+	endpoints, err := newEndpointPool(urls, offset, viper.GetDuration("rpc.retry"), log)
 	if err != nil {
-		log.Errorf("pending nonce failed: %v", err)
+		log.Errorf("ethClient initiate fialed: %v", err)
 		return nil, err
 	}
+	_, ethClient := endpoints.clients()
+	log.Debugf("bind endpoint %v", endpoints.endpoints[endpoints.current].url)
 
 	gasPrice, err := ethClient.SuggestGasPrice(context.Background())
 	if err != nil {
@@ -164,88 +291,251 @@ func New(blockchainBase *base.BlockchainBase) (client interface{}, err error) {
 		log.Errorf("get chainID failed: %v", err)
 		return nil, err
 	}
+	// all the nodes are on the chain of `rpc.chainid`, or the chain of bound node if it is not set
+	expectedChainID := viper.GetInt64("rpc.chainid")
+	if expectedChainID == 0 {
+		expectedChainID = chainID.Int64()
+	}
+	if err = checkEndpoints(endpoints, expectedChainID); err != nil {
+		log.Errorf("pre-flight check failed: %v", err)
+		return nil, err
+	}
+	head, err := ethClient.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		log.Errorf("get latest header failed: %v", err)
+		return nil, err
+	}
+	// london is active once the base fee appears in header
+	london := head.BaseFee != nil
+	signer := chainSigner(chainID, london)
+	txType := viper.GetString("tx.type")
+	if txType == "" {
+		txType = txTypeLegacy
+		if london {
+			txType = txTypeDynamic
+		}
+	}
+	if txType != txTypeLegacy && txType != txTypeDynamic {
+		log.Errorf("unknown transaction type: %v", txType)
+		return nil, errors.New("unknown transaction type: " + txType)
+	}
+	if txType == txTypeDynamic && !london {
+		log.Errorf("dynamic fee transaction is not supported before london")
+		return nil, errors.New("dynamic fee transaction is not supported before london")
+	}
 	auth, err := bind.NewKeyedTransactorWithChainID(PrivateK, chainID)
 	if err != nil {
 		log.Errorf("generate transaction options failed: %v", err)
 		return nil, err
 	}
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0)       // in wei
-	auth.GasLimit = uint64(gasLimit) // in units
+	auth.Value = big.NewInt(0) // in wei
 	auth.GasPrice = gasPrice
-	workerNum := uint64(len(viper.GetStringSlice(fcom.EngineURLsPath)))
-	if workerNum == 0 {
-		workerNum = 1
+	gasLimits, err := parseGasLimits(viper.GetStringSlice("gas.limits"))
+	if err != nil {
+		log.Errorf("load gas limits failed: %v", err)
+		return nil, err
+	}
+	confirmTimeout := viper.GetDuration("confirm.timeout")
+	if confirmTimeout <= 0 {
+		confirmTimeout = defaultConfirmTimeout
+	}
+	confirmInterval := viper.GetDuration("confirm.interval")
+	if confirmInterval <= 0 {
+		confirmInterval = defaultConfirmInterval
 	}
-	vmIdx := uint64(blockchainBase.VmID)
-	wkIdx := uint64(blockchainBase.WorkerID)
-	client = &ETH{
+	// confirm by new heads subscription if websocket is configured
+	var watcher *headWatcher
+	confirmMode := viper.GetString("confirm.mode")
+	if confirmMode == "" {
+		confirmMode = confirmPoll
+		if ws != "" {
+			confirmMode = confirmSubscribe
+		}
+	}
+	switch confirmMode {
+	case confirmPoll:
+	case confirmSubscribe:
+		watcher, err = getHeadWatcher(ws, log)
+		if err != nil {
+			// polling is the fallback
+			log.Errorf("subscribe new heads failed, poll receipts instead: %v", err)
+		}
+	default:
+		log.Errorf("unknown confirm mode: %v", confirmMode)
+		return nil, errors.New("unknown confirm mode: " + confirmMode)
+	}
+	e := &ETH{
 		BlockchainBase: blockchainBase,
-		ethClient:      ethClient,
+		endpoints:      endpoints,
+		fee:            newFeeOracle(endpoints, viper.GetInt("fee.blocks"), viper.GetFloat64("fee.percentile"), viper.GetDuration("fee.refresh")),
+		signer:         signer,
+		london:         london,
+		headers:        newHeaderCache(),
+		watcher:        watcher,
 		privateKey:     PrivateK,
 		publicKey:      PublicK,
 		auth:           auth,
 		chainID:        chainID,
 		gasPrice:       gasPrice,
+		gas:            newGasEstimator(viper.GetFloat64("gas.multiplier"), gasLimits),
+		accessLists:    &accessListCache{entries: make(map[string]*accessListEntry)},
 		Accounts:       accounts,
-		round:          0,
-		nonce:          nonce,
-		engineCap:      viper.GetUint64(fcom.EngineCapPath),
-		workerNum:      workerNum,
-		vmIdx:          vmIdx,
-		wkIdx:          wkIdx,
 		op: option{
-			setGas: false,
-			noSend: false,
+			setGas:          false,
+			noSend:          false,
+			txType:          txType,
+			sender:          senderFixed,
+			confirmTimeout:  confirmTimeout,
+			confirmInterval: confirmInterval,
+			confirmations:   viper.GetUint64("confirm.confirmations"),
 		},
 	}
-	return
+	var fill nonceFiller
+	if viper.GetBool("nonce.fill") {
+		fill = e.fillNonceGap
+	}
+	// master uses the nonces of accounts one by one before workers begin
+	stride := engineCap
+	if blockchainBase.WorkerID < 0 {
+		stride = 1
+	}
+	e.vmIndex, e.vmCount = offset, engineCap
+	e.random = rand.New(rand.NewSource(time.Now().UnixNano() + int64(offset)))
+	e.nonces = newNonceManager(endpoints, fill, stride, offset, viper.GetDuration("nonce.check"), blockchainBase.Logger)
+	e.senders = newSenderPool(accounts, fromAddress, offset, engineCap)
+	e.ownSenders(e.senders)
+	e.mainSender = e.senders.byAddress[fromAddress]
+	if e.mainSender == nil {
+		e.mainSender = newSender("", PrivateK)
+	}
+	return e, nil
 }
 func (e *ETH) DeployContract() error {
-	if e.BlockchainBase.ContractPath != "" {
-		var er error
-		e.contract, er = newContract(e.BlockchainBase.ContractPath)
-		if er != nil {
-			e.Logger.Errorf("initiate contract failed: %v", er)
-			return er
+	token, err := parseTokenOption(e.Options)
+	if err != nil {
+		e.Logger.Errorf("parse token option failed: %v", err)
+		return err
+	}
+	if token != nil {
+		if err = e.deployToken(token); err != nil {
+			e.Logger.Errorf("deploy token failed: %v", err)
+			return err
 		}
-	} else {
+	}
+	if e.BlockchainBase.ContractPath == "" {
 		return nil
 	}
-	parsed, err := abi.JSON(strings.NewReader(e.contract.ABI))
+	entries, err := parseContractEntries(viper.Get("contract.deploy"))
+	if err != nil {
+		e.Logger.Errorf("parse contracts to deploy failed: %v", err)
+		return err
+	}
+	if len(entries) > 0 {
+		if err = e.deployContracts(entries); err != nil {
+			e.Logger.Errorf("deploycontract failed: %v", err)
+			return err
+		}
+		return nil
+	}
+
+	e.contract, err = newContract(e.BlockchainBase.ContractPath)
+	if err != nil {
+		e.Logger.Errorf("initiate contract failed: %v", err)
+		return err
+	}
+	parsed, err := parseABI(e.contract.ABI)
 	if err != nil {
 		e.Logger.Errorf("decode abi of contract failed: %v", err)
 		return err
 	}
 	e.contract.parsedAbi = parsed
-	contractAddress, _, _, err := bind.DeployContract(e.auth, parsed, common.FromHex(e.contract.BIN), e.ethClient, e.Args...)
+	e.contract.Name = e.contract.artifact.name
+	e.contracts = map[string]*Contract{e.contract.Name: e.contract}
+
+	// the contract deployed before is used if its address is kept
+	file, err := addressFile(e.BlockchainBase.ContractPath, e.contract.Name)
 	if err != nil {
+		e.Logger.Errorf("read address of contract failed: %v", err)
+		return err
+	}
+	libs, err := parseLibraries(viper.GetStringSlice("contract.libraries"))
+	if err != nil {
+		e.Logger.Errorf("load libraries failed: %v", err)
+		return err
+	}
+	if err = e.setupContract(e.contract, file, e.Args, libs); err != nil {
 		e.Logger.Errorf("deploycontract failed: %v", err)
+		return err
 	}
-	e.contract.contractAddress = contractAddress
 	return nil
 }
 
-//Invoke invoke contract with funcName and args in eth network
-func (e *ETH) Invoke(invoke fcom.Invoke, ops ...fcom.Option) *fcom.Result {
-	instance := bind.NewBoundContract(e.contract.contractAddress, e.contract.parsedAbi, e.ethClient, e.ethClient, e.ethClient)
-	nonce := e.nonce + (e.wkIdx+e.round*e.workerNum)*(e.engineCap/e.workerNum) + e.vmIdx + 1
-	e.round++
+// deploy sends the transaction creating contract with bytecode in hex and constructor args,
+// the address of contract and the transaction are returned
+func (e *ETH) deploy(parsed abi.ABI, bin string, args ...interface{}) (common.Address, *types.Transaction, error) {
+	input, err := parsed.Pack("", args...)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	err = e.applyFee(e.auth)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	e.auth.GasLimit = e.deployGasLimit(append(common.FromHex(bin), input...))
+	nonce, err := e.nonces.next(fromAddress)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	e.auth.Nonce = new(big.Int).SetUint64(nonce)
+	contractAddress, tx, _, err := bind.DeployContract(e.auth, parsed, common.FromHex(bin), e.ethClient(), args...)
+	e.nonces.commit(fromAddress, nonce, err)
+	return contractAddress, tx, err
+}
 
-	e.auth.Nonce = big.NewInt(int64(nonce))
-	if e.op.setGas {
-		e.gasPrice = e.op.gas
+// waitDeployed waits for the receipt of transaction creating contract and checks the code of contract
+func (e *ETH) waitDeployed(address common.Address, tx *types.Transaction) error {
+	if err := e.waitTxs([]common.Hash{tx.Hash()}); err != nil {
+		return err
 	}
-	e.auth.NoSend = e.op.noSend
+	return e.checkCode(address)
+}
+
+// checkCode returns an error if there is no code at address
+func (e *ETH) checkCode(address common.Address) error {
+	code, err := e.ethClient().CodeAt(context.Background(), address, nil)
+	if err != nil {
+		return err
+	}
+	if len(code) == 0 {
+		return errors.New("no code at " + address.Hex())
+	}
+	return nil
+}
+
+//Invoke invoke contract with funcName and args in eth network,
+//funcName `__deploy` creates a new instance of contract with args of constructor
+func (e *ETH) Invoke(invoke fcom.Invoke, ops ...fcom.Option) *fcom.Result {
 	buildTime := time.Now().UnixNano()
-	tx, err := instance.Transact(e.auth, invoke.Func, invoke.Args...)
+	if tx := e.corpus.pop(invoke.Func); tx != nil {
+		return e.sendCorpusTx(tx, buildTime)
+	}
+	s, err := e.invokeSender(ops...)
+	var tx *types.Transaction
+	var endpoint string
+	if err == nil {
+		tx, endpoint, err = e.invokeTx(s, invoke)
+	}
 	sendTime := time.Now().UnixNano()
 	if err != nil {
 		e.Logger.Errorf("invoke error: %v", err)
+		ret := endpointRet(endpoint)
+		if revert, ok := err.(*revertError); ok {
+			ret = append(ret, map[string]interface{}{revertKey: revert.reason})
+		}
 		return &fcom.Result{
 			Label:     invoke.Func,
 			UID:       fcom.InvalidUID,
-			Ret:       []interface{}{},
+			Ret:       ret,
 			Status:    fcom.Failure,
 			BuildTime: buildTime,
 			SendTime:  sendTime,
@@ -254,7 +544,7 @@ func (e *ETH) Invoke(invoke fcom.Invoke, ops ...fcom.Option) *fcom.Result {
 	ret := &fcom.Result{
 		Label:     invoke.Func,
 		UID:       tx.Hash().String(),
-		Ret:       []interface{}{tx.Data()},
+		Ret:       withGasLimit(endpointRet(endpoint, tx.Data()), tx.Gas()),
 		Status:    fcom.Success,
 		BuildTime: buildTime,
 		SendTime:  sendTime,
@@ -264,6 +554,50 @@ func (e *ETH) Invoke(invoke fcom.Invoke, ops ...fcom.Option) *fcom.Result {
 
 }
 
+// invokeTx packs the input of contract method and sends the transaction calling it
+func (e *ETH) invokeTx(s *sender, invoke fcom.Invoke) (*types.Transaction, string, error) {
+	inv, err := e.packInvoke(invoke.Func, invoke.Args)
+	if err != nil {
+		return nil, "", err
+	}
+	if e.op.precheck {
+		if err = e.precheck(s.address, inv.to, big.NewInt(0), inv.data); err != nil {
+			return nil, "", err
+		}
+	}
+	gas, err := e.invocationGasLimit(s.address, invoke.Func, invoke.Args, inv)
+	if err != nil {
+		return nil, "", err
+	}
+	return e.sendTx(s, inv.to, big.NewInt(0), gas, inv.data, inv.accessList, !e.op.noSend)
+}
+
+// sendTx allocates the nonce of sender, signs the transaction with access list if it is not nil and sends it if send is true,
+// the result of sending is reported to nonce manager. The url of endpoint used is returned,
+// the transaction is sent again by the next endpoint if the endpoint failed.
+func (e *ETH) sendTx(s *sender, to *common.Address, value *big.Int, gas uint64, data []byte, list types.AccessList, send bool) (*types.Transaction, string, error) {
+	if s == nil || s.key == nil {
+		return nil, "", errors.New("no private key to sign transaction")
+	}
+	nonce, err := e.nonces.next(s.address)
+	if err != nil {
+		return nil, "", err
+	}
+	var url string
+	tx, err := e.signListTx(s.key, nonce, to, value, gas, data, list)
+	if err == nil && send {
+		var ep *endpoint
+		ep, err = e.endpoints.call(func(ep *endpoint) error {
+			return ep.ethClient.SendTransaction(context.Background(), tx)
+		})
+		if ep != nil {
+			url = ep.url
+		}
+	}
+	e.nonces.commit(s.address, nonce, err)
+	return tx, url, err
+}
+
 // Confirm check the result of `Invoke` or `Transfer`
 func (e *ETH) Confirm(result *fcom.Result, ops ...fcom.Option) *fcom.Result {
 	if result.UID == "" ||
@@ -272,14 +606,94 @@ func (e *ETH) Confirm(result *fcom.Result, ops ...fcom.Option) *fcom.Result {
 		result.Label == fcom.InvalidLabel {
 		return result
 	}
-	tx, _, err := e.ethClient.TransactionByHash(context.Background(), common.HexToHash(result.UID))
+	endpoint := resultEndpoint(result)
+	hash := common.HexToHash(result.UID)
+	r, header, err := e.confirmReceipt(hash)
+	reorg := ""
+	if err == nil && e.op.confirmations > 0 {
+		r, header, reorg, err = e.awaitConfirmations(hash, r)
+	}
 	result.ConfirmTime = time.Now().UnixNano()
-	if err != nil || tx == nil {
-		e.Logger.Errorf("query failed: %v", err)
+	if reorg != "" {
+		e.Logger.Noticef("transaction %v is %v by reorg", result.UID, reorg)
+	}
+	if err != nil {
+		e.Logger.Errorf("query receipt failed: %v", err)
 		result.Status = fcom.Unknown
+		if reorg != "" {
+			result.Ret = []interface{}{map[string]interface{}{reorgKey: reorg}}
+		}
 		return result
 	}
-	result.Status = fcom.Confirm
+	if reorg == reorgDropped {
+		result.Status = fcom.Unknown
+		result.Ret = []interface{}{map[string]interface{}{reorgKey: reorg}}
+		return result
+	}
+	if r.Status == types.ReceiptStatusSuccessful {
+		result.Status = fcom.Confirm
+	} else {
+		result.Status = fcom.Failure
+	}
+
+	info := map[string]interface{}{
+		"blockNumber": r.BlockNumber.Uint64(),
+		"gasUsed":     r.GasUsed,
+	}
+	if endpoint != "" {
+		info[endpointKey] = endpoint
+	}
+	if gas, ok := resultGasLimit(result); ok {
+		info[gasLimitKey] = gas
+	}
+	if e.op.confirmations > 0 {
+		info[confirmationsKey] = e.op.confirmations
+	}
+	if reorg != "" {
+		info[reorgKey] = reorg
+	}
+	if result.Status == fcom.Failure {
+		reason, err := e.replayRevert(r)
+		if err != nil {
+			e.Logger.Errorf("replay failed transaction %v failed: %v", result.UID, err)
+		} else {
+			info[revertKey] = reason
+		}
+	}
+	if r.ContractAddress != (common.Address{}) && result.Status == fcom.Confirm {
+		e.createdInfo(r, info)
+	}
+	e.sampleTrace(result.Label, hash, info)
+	result.Ret = []interface{}{info}
+	if header == nil {
+		header, err = e.headerByHash(r.BlockHash)
+	}
+	if err != nil {
+		e.Logger.Errorf("query block %v failed: %v", r.BlockHash.String(), err)
+	} else {
+		result.WriteTime = int64(header.Time) * int64(time.Second)
+		gasPrice, err := e.effectiveGasPrice(r, header)
+		if err != nil {
+			e.Logger.Errorf("query effective gas price failed: %v", err)
+		} else {
+			info["effectiveGasPrice"] = gasPrice.String()
+		}
+	}
+
+	if result.Label == fcom.BuiltinTransferLabel || e.contract == nil {
+		return result
+	}
+	events := e.decodeEvents(e.contractOf(result.Label), r.Logs)
+	emitted := false
+	expected := e.expectedEvent(result.Label)
+	for _, event := range events {
+		result.Ret = append(result.Ret, event)
+		emitted = emitted || event[eventKey] == expected
+	}
+	if expected != "" && !emitted && result.Status == fcom.Confirm {
+		e.Logger.Errorf("event %v is not emitted by %v", expected, result.UID)
+		result.Status = fcom.Failure
+	}
 	return result
 }
 
@@ -291,37 +705,41 @@ func (e *ETH) Verify(result *fcom.Result, ops ...fcom.Option) *fcom.Result {
 
 //Transfer transfer a amount of money from a account to the other one
 func (e *ETH) Transfer(args fcom.Transfer, ops ...fcom.Option) (result *fcom.Result) {
-	nonce := e.nonce + (e.wkIdx+e.round*e.workerNum)*(e.engineCap/e.workerNum) + e.vmIdx
-	e.round++
-
 	value := big.NewInt(args.Amount)
 
 	toAddress := common.HexToAddress(args.To)
 	data := []byte(args.Extra)
-	if e.op.setGas {
-		e.gasPrice = e.op.gas
-	}
-	tx := types.NewTransaction(nonce, toAddress, value, gasLimit, e.gasPrice, data)
 	buildTime := time.Now().UnixNano()
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(e.chainID), e.Accounts[args.From])
-	if err != nil {
-		return &fcom.Result{
-			Label:     fcom.BuiltinTransferLabel,
-			UID:       fcom.InvalidUID,
-			Ret:       []interface{}{},
-			Status:    fcom.Failure,
-			BuildTime: buildTime,
+	if tx := e.corpus.pop(fcom.BuiltinTransferLabel); tx != nil {
+		return e.sendCorpusTx(tx, buildTime)
+	}
+	s := e.senders.byAlias[args.From]
+	var (
+		gas      uint64
+		signedTx *types.Transaction
+		endpoint string
+	)
+	err := e.senders.check(s)
+	if err == nil {
+		if e.token != nil {
+			// the token is transferred instead of ether
+			data = tokenTransfer(toAddress, value)
+			toAddress, value = *e.token, big.NewInt(0)
+			if s != nil {
+				gas = e.tokenGasLimit(s.address, data)
+			}
+		} else if s != nil {
+			gas = e.transferGasLimit(s.address, &toAddress, value, data)
 		}
+		signedTx, endpoint, err = e.sendTx(s, &toAddress, value, gas, data, nil, true)
 	}
-
-	err = e.ethClient.SendTransaction(context.Background(), signedTx)
 	sendTime := time.Now().UnixNano()
 	if err != nil {
 		e.Logger.Errorf("transfer error: %v", err)
 		return &fcom.Result{
 			Label:     fcom.BuiltinTransferLabel,
 			UID:       fcom.InvalidUID,
-			Ret:       []interface{}{},
+			Ret:       endpointRet(endpoint),
 			Status:    fcom.Failure,
 			BuildTime: buildTime,
 			SendTime:  sendTime,
@@ -331,7 +749,7 @@ func (e *ETH) Transfer(args fcom.Transfer, ops ...fcom.Option) (result *fcom.Res
 	ret := &fcom.Result{
 		Label:     fcom.BuiltinTransferLabel,
 		UID:       signedTx.Hash().String(),
-		Ret:       []interface{}{tx.Data()},
+		Ret:       withGasLimit(endpointRet(endpoint, signedTx.Data()), signedTx.Gas()),
 		Status:    fcom.Success,
 		BuildTime: buildTime,
 		SendTime:  sendTime,
@@ -358,14 +776,38 @@ func (e *ETH) SetContext(context string) error {
 
 	// set contractaddress,abi,publickey
 	e.contract = msg.Contract
+	e.contracts = msg.Contracts
+	if e.contracts == nil && e.contract != nil {
+		e.contracts = map[string]*Contract{e.contract.Name: e.contract}
+	}
+	for name, c := range e.contracts {
+		parsed, err := parseABI(c.ABI)
+		if err != nil {
+			e.Logger.Errorf("decode abi of contract %v failed: %v", name, err)
+			return err
+		}
+		c.parsedAbi = parsed
+	}
+	// the default contract is the same one in the address book
 	if e.contract != nil {
-		parsed, err := abi.JSON(strings.NewReader(e.contract.ABI))
+		if c, ok := e.contracts[e.contract.Name]; ok {
+			e.contract = c
+		}
+	}
+	e.token = msg.Token
+	for account, nonce := range msg.Nonces {
+		e.nonces.setBase(common.HexToAddress(account), nonce)
+	}
+	instants := make(map[string]*ecdsa.PrivateKey, len(msg.Instants))
+	for alias, hexKey := range msg.Instants {
+		key, err := crypto.HexToECDSA(hexKey)
 		if err != nil {
-			e.Logger.Errorf("decode abi of contract failed: %v", err)
+			e.Logger.Errorf("decode instant account %v failed: %v", alias, err)
 			return err
 		}
-		e.contract.parsedAbi = parsed
+		instants[alias] = key
 	}
+	e.useInstants(instants)
 	publicKey := e.privateKey.Public()
 	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
 	if !ok {
@@ -383,9 +825,50 @@ func (e *ETH) ResetContext() error {
 
 //GetContext generate TxContext
 func (e *ETH) GetContext() (string, error) {
+	if count := cast.ToInt(e.Options["instant"]); count > 0 && e.instants == nil {
+		fund, err := parseInstantFund(e.Options["instantfund"])
+		if err != nil {
+			e.Logger.Errorf("create instant accounts failed: %v", err)
+			return "", err
+		}
+		e.instants, err = e.createInstants(count, fund)
+		if err != nil {
+			e.Logger.Errorf("create instant accounts failed: %v", err)
+			return "", err
+		}
+	}
+
+	if e.tokenFund != nil {
+		if err := e.fundToken(e.tokenAccounts(), e.tokenFund); err != nil {
+			e.Logger.Errorf("fund token failed: %v", err)
+			return "", err
+		}
+		e.tokenFund = nil
+	}
 
+	addresses := []common.Address{fromAddress}
+	for _, key := range e.Accounts {
+		if address := crypto.PubkeyToAddress(key.PublicKey); address != fromAddress {
+			addresses = append(addresses, address)
+		}
+	}
+	nonces, err := e.nonces.bases(addresses)
+	if err != nil {
+		e.Logger.Errorf("get nonces of accounts failed: %v", err)
+	}
 	msg := &Msg{
 		Contract: e.contract,
+		Nonces:   nonces,
+		Token:    e.token,
+	}
+	if len(e.contracts) > 1 {
+		msg.Contracts = e.contracts
+	}
+	if len(e.instants) > 0 {
+		msg.Instants = make(map[string]string, len(e.instants))
+		for alias, key := range e.instants {
+			msg.Instants[alias] = hex.EncodeToString(crypto.FromECDSA(key))
+		}
 	}
 
 	bytes, err := json.Marshal(msg)
@@ -404,20 +887,23 @@ func (e *ETH) Statistic(statistic fcom.Statistic) (*fcom.RemoteStatistic, error)
 	return statisticData, nil
 }
 
-// LogStatus records blockheight and time
+// LogStatus records blockheight and time, the status of node is logged with them
 func (e *ETH) LogStatus() (chainInfo *fcom.ChainInfo, err error) {
-	blockInfo, err := e.ethClient.HeaderByNumber(context.Background(), nil)
+	blockInfo, err := e.ethClient().HeaderByNumber(context.Background(), nil)
 	if err != nil {
 		return nil, err
 	}
-	return &fcom.ChainInfo{BlockHeight: blockInfo.Number.Int64(), TimeStamp: time.Now().UnixNano()}, err
+	chainInfo = &fcom.ChainInfo{BlockHeight: blockInfo.Number.Int64(), TimeStamp: time.Now().UnixNano()}
+	e.logNodeStatus(chainInfo.TimeStamp, blockInfo.Number)
+	e.trackReorgs()
+	return chainInfo, nil
 }
 
 // Option ethereum receive options to change the config to client.
 // Supported Options:
 // 1. key: gas
 //    valueType: int
-//    effect: set gas will set gasprice used for transaction
+//    effect: set gas will set gasprice used for legacy transaction
 //            not set gas will let client use gas which initiate when client created
 //    default: default setGas is false, gas is what initiate when client created
 // 2. key: nosend
@@ -425,7 +911,81 @@ func (e *ETH) LogStatus() (chainInfo *fcom.ChainInfo, err error) {
 //    effect: set nosend true will let client do not send transaction to node when invoking contract
 //            set nosend false will let client send transaction to node when invoking contract
 //    default: default nosend is false, gas is what initiate when client created
+// 3. key: txtype
+//    valueType: string
+//    effect: `legacy` will let client send legacy transactions priced by gas price
+//            `dynamic` will let client send EIP-1559 transactions priced by maxFeePerGas and maxPriorityFeePerGas
+//    default: `tx.type` in eth.toml, or `dynamic` if london is active and `legacy` if not
+// 4. key: maxfee
+//    valueType: int
+//    effect: set maxfee will fix maxFeePerGas of dynamic fee transaction
+//            not set maxfee will let client use twice the base fee of next block plus maxPriorityFeePerGas
+//    default: computed from eth_feeHistory
+// 5. key: maxpriorityfee
+//    valueType: int
+//    effect: set maxpriorityfee will fix maxPriorityFeePerGas of dynamic fee transaction
+//            not set maxpriorityfee will let client use the median of the rewards in eth_feeHistory
+//    default: computed from eth_feeHistory
+// 6. key: event
+//    valueType: string or table
+//    effect: set event will let confirmed invoke fail if the event is not emitted,
+//            a string is expected for all functions and a table maps function name to event name
+//    default: no event is expected
+// 7. key: account
+//    valueType: string
+//    effect: set account will let client invoke contract with the account of the alias,
+//            it can also be set in the options of a single invoke
+//    default: the first account in keystore
+// 8. key: sender
+//    valueType: string
+//    effect: set the strategy to choose the sender of invoking contract,
+//            `fixed` uses the account set by option `account`,
+//            `roundrobin` uses the accounts of vm in turn,
+//            `sticky` always uses the first account of vm,
+//            `random` uses a random account of vm.
+//            Each vm owns disjoint accounts if there are no less accounts than vms
+//    default: fixed
+// 9. key: corpus
+//    valueType: table
+//    effect: set corpus will sign `count` transactions of vm before benchmark with the senders, nonces and fees of the options,
+//            invoking `func` with `args`, or transferring `value` to `to` if `func` is not set;
+//            `Invoke` and `Transfer` then send the raw transactions until they are used up.
+//            If `file` is set, the corpus is saved to it or loaded from it if it exists, `{vm}` in it is replaced by the index of vm.
+//            The corpus is built after the other options in the same call
+//    default: no corpus
+// 10. key: precheck
+//    valueType: bool
+//    effect: set precheck true will let client call the contract on pending state before sending transaction,
+//            the invoking fails with the revert reason if the call reverts
+//    default: false
+// 11. key: gaslimit
+//    valueType: int or table
+//    effect: set gaslimit will fix the gas limit of transactions,
+//            an int is expected for all functions and a table maps function name or signature to its limit,
+//            `transfer` is the name of transferring and `constructor` is the name of deploying contract
+//    default: `gas.limits` in eth.toml, or the estimated gas multiplied by `gas.multiplier` cached by function signature
+// 12. key: confirmations
+//    valueType: int
+//    effect: set confirmations will let confirm wait until the number of blocks are on top of the inclusion block,
+//            the block hash is checked again then. The transaction dropped by reorg is unknown with `reorg` of `dropped`,
+//            and the one included in another block is confirmed by the new block with `reorg` of `reincluded`
+//    default: `confirm.confirmations` in eth.toml, or 0 for confirming once the receipt is found
+// 13. key: trace
+//    valueType: bool or table
+//    effect: set trace will let confirm trace the sampled transactions by debug_traceTransaction,
+//            the trace is summarized into the gas by opcode, the count of SLOAD and SSTORE and the max depth of calls.
+//            The table has `rate` for the ratio sampled, `tracer` of `struct` for the struct logger or `call` for callTracer
+//            summarized into the count of calls by type, and `dir` to dump the summaries to `{label}.trace` in it
+//            instead of returning them with `trace` key of result. true traces all transactions by struct logger
+//    default: no trace
+// 14. key: accesslist
+//    valueType: bool or string
+//    effect: set accesslist will let client invoke contract by EIP-2930 transactions with the access list created by
+//            eth_createAccessList, or by dynamic fee transactions with it if `txtype` is dynamic. The access list and
+//            its gas are cached by function signature with `sig` or true, and by function signature and args with `args`
+//    default: no access list
 func (e *ETH) Option(options fcom.Option) error {
+	var corpusOp *corpusOption
 	for key, value := range options {
 		switch key {
 		case "gas":
@@ -441,6 +1001,105 @@ func (e *ETH) Option(options fcom.Option) error {
 			} else {
 				return errors.New("option `nosend` type error: " + reflect.TypeOf(value).Name())
 			}
+		case "txtype":
+			txType, ok := value.(string)
+			if !ok {
+				return errors.New("option `txtype` type error: " + reflect.TypeOf(value).Name())
+			}
+			switch txType {
+			case txTypeLegacy:
+			case txTypeDynamic:
+				if !e.london {
+					return errors.New("option `txtype` error: dynamic fee transaction is not supported before london")
+				}
+			default:
+				return errors.New("option `txtype` value error: " + txType)
+			}
+			e.op.txType = txType
+		case "maxfee":
+			if maxFee, ok := value.(float64); ok {
+				e.op.maxFee = big.NewInt(int64(maxFee))
+			} else {
+				return errors.New("option `maxfee` type error: " + reflect.TypeOf(value).Name())
+			}
+		case "maxpriorityfee":
+			if maxPriorityFee, ok := value.(float64); ok {
+				e.op.maxPriorityFee = big.NewInt(int64(maxPriorityFee))
+			} else {
+				return errors.New("option `maxpriorityfee` type error: " + reflect.TypeOf(value).Name())
+			}
+		case "account":
+			account, ok := value.(string)
+			if !ok {
+				return errors.New("option `account` type error: " + reflect.TypeOf(value).Name())
+			}
+			s, ok := e.senders.byAlias[account]
+			if !ok {
+				return errors.New("option `account` error: unknown account " + account)
+			}
+			if err := e.senders.check(s); err != nil {
+				return errors.New("option `account` error: " + err.Error())
+			}
+			e.op.account, e.op.sender = account, senderFixed
+		case "sender":
+			strategy, ok := value.(string)
+			if !ok {
+				return errors.New("option `sender` type error: " + reflect.TypeOf(value).Name())
+			}
+			switch strategy {
+			case senderFixed, senderRoundRobin, senderSticky, senderRandom:
+				e.op.sender = strategy
+			default:
+				return errors.New("option `sender` value error: " + strategy)
+			}
+		case "event":
+			events, err := parseEventOption(value)
+			if err != nil {
+				return err
+			}
+			e.op.events = events
+		case "precheck":
+			if precheck, ok := value.(bool); ok {
+				e.op.precheck = precheck
+			} else {
+				return errors.New("option `precheck` type error: " + reflect.TypeOf(value).Name())
+			}
+		case "confirmations":
+			confirmations, err := parseConfirmations(value)
+			if err != nil {
+				return err
+			}
+			e.op.confirmations = confirmations
+		case "trace":
+			op, err := parseTraceOption(value)
+			if err != nil {
+				return err
+			}
+			e.op.trace = op
+		case "accesslist":
+			mode, err := parseAccessListOption(value)
+			if err != nil {
+				return err
+			}
+			e.op.accessList = mode
+		case "gaslimit":
+			limits, err := parseGasLimitOption(value)
+			if err != nil {
+				return err
+			}
+			e.op.gasLimits = limits
+		case "corpus":
+			op, err := parseCorpusOption(value)
+			if err != nil {
+				return err
+			}
+			corpusOp = op
+		}
+	}
+	if corpusOp != nil {
+		if err := e.prepareCorpus(corpusOp); err != nil {
+			e.Logger.Errorf("prepare corpus failed: %v", err)
+			return err
 		}
 	}
 	return nil
@@ -468,54 +1127,57 @@ func KeystoreToPrivateKey(privateKeyFile, password string) (string, string, erro
 // GetTPS calculates txnum and blocknum of pressure test
 func GetTPS(e *ETH, statistic fcom.Statistic) (*fcom.RemoteStatistic, error) {
 	from, to := statistic.From.TimeStamp, statistic.To.TimeStamp
-	blockCounter, txCounter := 0, 0
 	duration := float64(to - from)
 
-	for i := statistic.From.BlockHeight; i < statistic.To.BlockHeight; i++ {
-		block, err := e.ethClient.BlockByNumber(context.Background(), new(big.Int).SetInt64(i))
-		if err != nil {
-			return nil, err
-		}
-		txCounter += len(block.Transactions())
-		blockCounter++
+	// the parent of first block is scanned for the start of chain time
+	start := statistic.From.BlockHeight - 1
+	if start < 0 {
+		start = 0
 	}
+	var blocks []*blockStat
+	_, err := e.endpoints.call(func(ep *endpoint) (err error) {
+		blocks, err = statisticScanner(ep.rpcClient).scan(start, statistic.To.BlockHeight)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var parent *blockStat
+	if start < statistic.From.BlockHeight && len(blocks) > 0 {
+		parent, blocks = blocks[0], blocks[1:]
+	}
+	chain := summarize(parent, blocks)
+	e.logChainStatistic(chain, time.Duration(to-from))
+	e.logReverts(blocks)
+	e.logReorgs(from, to)
+	e.logStatusChange(from, to)
 
 	return &fcom.RemoteStatistic{
 		Start:    from,
 		End:      to,
-		BlockNum: blockCounter,
-		TxNum:    txCounter,
-		CTps:     float64(txCounter) * float64(time.Second) / duration,
-		Bps:      float64(blockCounter) * float64(time.Second) / duration,
+		BlockNum: chain.blocks,
+		TxNum:    chain.txs,
+		CTps:     float64(chain.txs) * float64(time.Second) / duration,
+		Bps:      float64(chain.blocks) * float64(time.Second) / duration,
 	}, nil
 }
 
-// newContract initiates abi and bin files of contract
+// newContract loads the contract to deploy from contract path, it is chosen by `contract.name` in eth.toml
+// if there are several contracts. The bytecode is linked when it is deployed.
 func newContract(contractPath string) (contract *Contract, err error) {
-	files, err := ioutil.ReadDir(contractPath)
-	var abiData, binData []byte
+	artifacts, err := loadArtifacts(contractPath)
 	if err != nil {
 		return nil, err
 	}
-	for _, file := range files {
-		if path.Ext(file.Name()) == ".abi" {
-			abiData, err = ioutil.ReadFile(contractPath + "/" + file.Name())
-			if err != nil {
-				return nil, err
-			}
-		}
-		if path.Ext(file.Name()) == ".bin" {
-			binData, err = ioutil.ReadFile(contractPath + "/" + file.Name())
-			if err != nil {
-				return nil, err
-			}
-		}
+	main, err := mainArtifact(artifacts, viper.GetString("contract.name"))
+	if err != nil {
+		return nil, err
 	}
-	abi := (string)(abiData)
-	bin := (string)(binData)
 	contract = &Contract{
-		ABI: abi,
-		BIN: bin,
+		ABI:       main.abi,
+		BIN:       main.bin,
+		artifact:  main,
+		artifacts: artifacts,
 	}
 	return contract, nil
 }
@@ -1,10 +1,20 @@
 package main
 
 import (
+	"crypto/ecdsa"
+	"encoding/json"
 	"io/ioutil"
+	"math/big"
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+
 	"github.com/hyperbench/hyperbench-common/base"
 	fcom "github.com/hyperbench/hyperbench-common/common"
 
@@ -213,7 +223,8 @@ func TestTransaction(t *testing.T) {
 	res = client.Verify(res)
 	assert.Equal(t, res.Status, fcom.Status("unknown"))
 
-	client.nonce -= 1
+	// reuse the nonce of last transfer
+	client.nonces.accounts[common.HexToAddress("74d366e0649a91395bb122c005917644382b9452")].round--
 	res = client.Transfer(fcom.Transfer{From: "74d366e0649a91395bb122c005917644382b9452", To: "74d366e0649a91395bb122c005917644382b9452", Amount: int64(1)})
 	assert.Equal(t, res.Status, fcom.Status("failure"))
 
@@ -278,3 +289,314 @@ func TestTransaction(t *testing.T) {
 	assert.NoError(t, err)
 
 }
+
+func TestFeeHistory(t *testing.T) {
+	history := &feeHistory{}
+	_, _, err := history.suggestion()
+	assert.Error(t, err)
+
+	err = json.Unmarshal([]byte(`{"oldestBlock":"0x1","baseFeePerGas":["0x10","0x20","0x30"],"reward":[["0x5"],["0x1"],["0x3"]],"gasUsedRatio":[0.5,0.5]}`), history)
+	assert.NoError(t, err)
+	baseFee, tip, err := history.suggestion()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0x30), baseFee.Int64())
+	assert.Equal(t, int64(3), tip.Int64())
+
+	history.Reward = nil
+	_, tip, err = history.suggestion()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), tip.Int64())
+}
+
+func TestHeaderCache(t *testing.T) {
+	cache := newHeaderCache()
+	first := &types.Header{Number: big.NewInt(0)}
+	cache.add(first)
+	assert.Equal(t, first, cache.get(first.Hash()))
+
+	for i := 1; i <= headerCacheSize; i++ {
+		cache.add(&types.Header{Number: big.NewInt(int64(i))})
+	}
+	assert.Nil(t, cache.get(first.Hash()))
+	assert.Len(t, cache.headers, headerCacheSize)
+}
+
+func TestDecodeEvents(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(`[{"anonymous":false,"inputs":[{"indexed":true,"internalType":"address","name":"from","type":"address"},{"indexed":false,"internalType":"uint256","name":"value","type":"uint256"}],"name":"Sent","type":"event"}]`))
+	assert.NoError(t, err)
+	contract := &Contract{parsedAbi: parsed}
+	e := &ETH{BlockchainBase: base.NewBlockchainBase(base.ClientConfig{})}
+
+	from := common.HexToAddress("0x74d366e0649a91395bb122c005917644382b9452")
+	data, err := parsed.Events["Sent"].Inputs.NonIndexed().Pack(big.NewInt(10))
+	assert.NoError(t, err)
+	sent := &types.Log{Address: from, Topics: []common.Hash{parsed.Events["Sent"].ID, common.BytesToHash(from.Bytes())}, Data: data}
+	// the malformed log is skipped, the logs after it are still decoded
+	logs := []*types.Log{
+		sent,
+		{Address: from, Topics: []common.Hash{parsed.Events["Sent"].ID}, Data: []byte{1}},
+		{Address: from, Topics: []common.Hash{common.HexToHash("0x1")}},
+		{Address: from},
+		sent,
+	}
+	events := e.decodeEvents(contract, logs)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "Sent", events[0][eventKey])
+	assert.Equal(t, from.Hex(), events[0]["from"])
+	assert.Equal(t, "10", events[0]["value"])
+	assert.Equal(t, events[0], events[1])
+
+	event, err := contract.decodeEvent(logs[1])
+	assert.Error(t, err)
+	assert.Nil(t, event)
+}
+
+func TestEventOption(t *testing.T) {
+	e := &ETH{}
+	assert.NoError(t, e.Option(fcom.Option{"event": "Sent"}))
+	assert.Equal(t, "Sent", e.expectedEvent("transfer"))
+
+	assert.NoError(t, e.Option(fcom.Option{"event": map[interface{}]interface{}{"transfer": "Sent"}}))
+	assert.Equal(t, "Sent", e.expectedEvent("transfer"))
+	assert.Equal(t, "", e.expectedEvent("approve"))
+
+	assert.Error(t, e.Option(fcom.Option{"event": float64(1)}))
+	assert.Error(t, e.Option(fcom.Option{"event": map[interface{}]interface{}{float64(1): "Sent"}}))
+}
+
+func TestQueryOption(t *testing.T) {
+	block, pending, err := parseBlockOption()
+	assert.NoError(t, err)
+	assert.Nil(t, block)
+	assert.False(t, pending)
+
+	block, pending, err = parseBlockOption(fcom.Option{"block": float64(10)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), block.Int64())
+	assert.False(t, pending)
+
+	block, _, err = parseBlockOption(fcom.Option{"block": "0x10"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(16), block.Int64())
+
+	block, pending, err = parseBlockOption(fcom.Option{"block": "pending"})
+	assert.NoError(t, err)
+	assert.Nil(t, block)
+	assert.True(t, pending)
+
+	_, _, err = parseBlockOption(fcom.Option{"block": true})
+	assert.Error(t, err)
+	_, _, err = parseBlockOption(fcom.Option{"block": "earliest"})
+	assert.Error(t, err)
+	_, err = parseUint(float64(1.5))
+	assert.Error(t, err)
+
+	e := &ETH{BlockchainBase: base.NewBlockchainBase(base.ClientConfig{})}
+	assert.Nil(t, e.Query(fcom.Query{Func: BuiltinBalanceLabel}))
+	assert.Nil(t, e.Query(fcom.Query{Func: BuiltinStorageLabel, Args: []interface{}{"0x74d366e0649a91395bb122c005917644382b9452"}}))
+	assert.Nil(t, e.Query(fcom.Query{Func: "version"}))
+}
+
+func TestSenderPool(t *testing.T) {
+	accounts := make(map[string]*ecdsa.PrivateKey)
+	for _, alias := range []string{"a", "b", "c", "d", "e"} {
+		key, err := crypto.GenerateKey()
+		assert.NoError(t, err)
+		accounts[alias] = key
+	}
+
+	// exclusive
+	p := newSenderPool(accounts, common.Address{}, 1, 2)
+	assert.True(t, p.exclusive)
+	assert.Len(t, p.own, 2)
+	assert.Equal(t, "b", p.choose(senderRoundRobin).alias)
+	assert.Equal(t, "d", p.choose(senderRoundRobin).alias)
+	assert.Equal(t, "b", p.choose(senderRoundRobin).alias)
+	assert.Equal(t, "b", p.choose(senderSticky).alias)
+	assert.Contains(t, []string{"b", "d"}, p.choose(senderRandom).alias)
+	assert.Equal(t, p.byAlias["c"], p.byAddress[crypto.PubkeyToAddress(accounts["c"].PublicKey)])
+
+	// shared
+	p = newSenderPool(accounts, common.Address{}, 3, 10)
+	assert.False(t, p.exclusive)
+	assert.Len(t, p.own, 5)
+
+	e := &ETH{senders: p, mainSender: p.byAlias["e"], op: option{sender: senderFixed}}
+	s, err := e.invokeSender()
+	assert.NoError(t, err)
+	assert.Equal(t, "e", s.alias)
+	s, err = e.invokeSender(fcom.Option{"account": "c"})
+	assert.NoError(t, err)
+	assert.Equal(t, "c", s.alias)
+	_, err = e.invokeSender(fcom.Option{"account": "f"})
+	assert.Error(t, err)
+	_, err = e.invokeSender(fcom.Option{"account": float64(1)})
+	assert.Error(t, err)
+
+	assert.NoError(t, e.Option(fcom.Option{"sender": senderSticky}))
+	s, _ = e.invokeSender()
+	assert.Equal(t, "a", s.alias)
+	assert.NoError(t, e.Option(fcom.Option{"account": "d"}))
+	s, _ = e.invokeSender()
+	assert.Equal(t, "d", s.alias)
+	assert.Error(t, e.Option(fcom.Option{"account": "f"}))
+	assert.Error(t, e.Option(fcom.Option{"sender": "first"}))
+}
+
+func TestExclusiveMainSender(t *testing.T) {
+	accounts := make(map[string]*ecdsa.PrivateKey)
+	for _, alias := range []string{"a", "b", "main"} {
+		key, err := crypto.GenerateKey()
+		assert.NoError(t, err)
+		accounts[alias] = key
+	}
+	main := crypto.PubkeyToAddress(accounts["main"].PublicKey)
+	vms := make([]*ETH, 2)
+	for i := range vms {
+		senders := newSenderPool(accounts, main, uint64(i), 2)
+		vms[i] = &ETH{
+			BlockchainBase: base.NewBlockchainBase(base.ClientConfig{}),
+			senders:        senders,
+			mainSender:     senders.byAlias["main"],
+			nonces:         newNonceManager(&fakeNonceSource{}, nil, 2, uint64(i), 0, fcom.GetLogger("eth")),
+			op:             option{sender: senderFixed},
+		}
+		vms[i].ownSenders(senders)
+		assert.True(t, senders.exclusive)
+		assert.Len(t, senders.own, 1)
+	}
+
+	// both vms send from the main account by fixed strategy, its nonces stay partitioned
+	seen := make(map[uint64]bool)
+	for round := 0; round < 3; round++ {
+		for _, e := range vms {
+			s, err := e.invokeSender()
+			assert.NoError(t, err)
+			assert.Equal(t, main, s.address)
+			nonce, err := e.nonces.next(s.address)
+			assert.NoError(t, err)
+			assert.False(t, seen[nonce], "nonce %v is allocated twice", nonce)
+			seen[nonce] = true
+			e.nonces.commit(s.address, nonce, nil)
+		}
+	}
+	assert.False(t, vms[0].nonces.exclusive[main])
+
+	// the account owned by the other vm is refused
+	_, err := vms[0].invokeSender(fcom.Option{"account": "b"})
+	assert.Error(t, err)
+	assert.Error(t, vms[0].Option(fcom.Option{"account": "b"}))
+	assert.NoError(t, vms[1].Option(fcom.Option{"account": "b"}))
+	res := vms[0].Transfer(fcom.Transfer{From: "b", To: main.Hex(), Amount: 1})
+	assert.Equal(t, fcom.Failure, res.Status)
+}
+
+func TestInstants(t *testing.T) {
+	main, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	shared := map[string]*ecdsa.PrivateKey{"main": main}
+	instants := make(map[string]*ecdsa.PrivateKey)
+	for i := 0; i < 4; i++ {
+		key, err := crypto.GenerateKey()
+		assert.NoError(t, err)
+		instants[accountAlias(key)] = key
+	}
+
+	e := &ETH{
+		senders:  newSenderPool(shared, crypto.PubkeyToAddress(main.PublicKey), 1, 2),
+		nonces:   newNonceManager(&fakeNonceSource{}, nil, 2, 1, 0, fcom.GetLogger("eth")),
+		Accounts: shared,
+		vmIndex:  1,
+		vmCount:  2,
+		op:       option{sender: senderFixed},
+	}
+	e.useInstants(instants)
+	assert.True(t, e.senders.exclusive)
+	assert.Len(t, e.senders.own, 2)
+	assert.Equal(t, senderRoundRobin, e.op.sender)
+	assert.Len(t, e.Accounts, 5)
+	// keystore accounts shared by vms are not changed
+	assert.Len(t, shared, 1)
+	assert.NotNil(t, e.senders.byAlias["main"])
+	for _, s := range e.senders.own {
+		assert.NotNil(t, instants[s.alias])
+		assert.True(t, e.nonces.exclusive[s.address])
+	}
+
+	fund, err := parseInstantFund(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "1000000000000000000", fund.String())
+	fund, err = parseInstantFund("0x10")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(16), fund.Int64())
+	_, err = parseInstantFund(true)
+	assert.Error(t, err)
+}
+
+func TestCorpus(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	accounts := map[string]*ecdsa.PrivateKey{"main": key}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	newClient := func() *ETH {
+		senders := newSenderPool(accounts, address, 0, 1)
+		return &ETH{
+			BlockchainBase: base.NewBlockchainBase(base.ClientConfig{}),
+			senders:        senders,
+			mainSender:     senders.byAlias["main"],
+			nonces:         newNonceManager(&fakeNonceSource{}, nil, 1, 0, 0, fcom.GetLogger("eth")),
+			signer:         types.NewEIP155Signer(big.NewInt(1)),
+			chainID:        big.NewInt(1),
+			gasPrice:       big.NewInt(1),
+			gas:            newGasEstimator(0, map[string]uint64{transferGasKey: params.TxGas}),
+			op:             option{sender: senderFixed},
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "corpus")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := dir + "/corpus-{vm}.json"
+	op, err := parseCorpusOption(map[interface{}]interface{}{
+		"count": float64(3),
+		"to":    "0x74d366e0649a91395bb122c005917644382b9452",
+		"value": "0x10",
+		"file":  file,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fcom.BuiltinTransferLabel, op.label)
+
+	// build and save
+	e := newClient()
+	assert.NoError(t, e.prepareCorpus(op))
+	assert.Len(t, e.corpus.txs[fcom.BuiltinTransferLabel], 3)
+	first := e.corpus.txs[fcom.BuiltinTransferLabel][0]
+	assert.Equal(t, uint64(0), first.nonce)
+	assert.Equal(t, address, first.sender)
+	next, err := e.nonces.next(address)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), next)
+	_, err = os.Stat(dir + "/corpus-0.json")
+	assert.NoError(t, err)
+
+	// load the saved corpus, nonces are moved after it
+	loaded := newClient()
+	assert.NoError(t, loaded.prepareCorpus(op))
+	txs := loaded.corpus.txs[fcom.BuiltinTransferLabel]
+	assert.Len(t, txs, 3)
+	for i, tx := range txs {
+		assert.Equal(t, uint64(i), tx.nonce)
+		assert.Equal(t, address, tx.sender)
+		assert.Equal(t, e.corpus.txs[fcom.BuiltinTransferLabel][i].hash, tx.hash)
+	}
+	next, err = loaded.nonces.next(address)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), next)
+	assert.Equal(t, first.hash, loaded.corpus.pop(fcom.BuiltinTransferLabel).hash)
+	assert.Nil(t, loaded.corpus.pop("unknown"))
+
+	_, err = parseCorpusOption(map[interface{}]interface{}{"func": "set"})
+	assert.Error(t, err)
+	_, err = parseCorpusOption(map[interface{}]interface{}{"count": float64(1), "to": "alice"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,107 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide decoding of contract events in receipt logs
+ * @file event.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// eventKey is the key of event name in decoded event
+	eventKey = "event"
+	// addressKey is the key of emitting contract address in decoded event
+	addressKey = "address"
+)
+
+// decodeEvent decodes the log if it matches an event of contract abi, nil is returned if it does not match.
+// The event is decoded to a map containing the event name, the emitting address and the named arguments
+func (c *Contract) decodeEvent(log *types.Log) (map[string]interface{}, error) {
+	if len(log.Topics) == 0 {
+		return nil, nil
+	}
+	// anonymous events and events of other contracts can not be found by topic
+	event, err := c.parsedAbi.EventByID(log.Topics[0])
+	if err != nil {
+		return nil, nil
+	}
+	args := make(map[string]interface{})
+	if len(log.Data) > 0 {
+		if err = event.Inputs.NonIndexed().UnpackIntoMap(args, log.Data); err != nil {
+			return nil, err
+		}
+	}
+	var indexed abi.Arguments
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if err = abi.ParseTopicsIntoMap(args, indexed, log.Topics[1:]); err != nil {
+		return nil, err
+	}
+
+	decoded := make(map[string]interface{}, len(args)+2)
+	for name, arg := range args {
+		decoded[name] = plainValue(arg)
+	}
+	decoded[eventKey] = event.Name
+	decoded[addressKey] = log.Address.Hex()
+	return decoded, nil
+}
+
+// expectedEvent returns the event which should be emitted by invoking the function,
+// set by option `event`
+func (e *ETH) expectedEvent(funcName string) string {
+	if event, ok := e.op.events[funcName]; ok {
+		return event
+	}
+	return e.op.events[""]
+}
+
+// parseEventOption parses the value of option `event`,
+// a string is expected for all functions and a table maps function name to event name
+func parseEventOption(value interface{}) (map[string]string, error) {
+	switch v := value.(type) {
+	case string:
+		return map[string]string{"": v}, nil
+	case map[interface{}]interface{}:
+		events := make(map[string]string, len(v))
+		for funcName, event := range v {
+			f, ok := funcName.(string)
+			if !ok {
+				return nil, errors.New("option `event` key type error: " + reflect.TypeOf(funcName).Name())
+			}
+			ev, ok := event.(string)
+			if !ok {
+				return nil, errors.New("option `event` value type error: " + reflect.TypeOf(event).Name())
+			}
+			events[f] = ev
+		}
+		return events, nil
+	default:
+		return nil, errors.New("option `event` type error: " + reflect.TypeOf(value).Name())
+	}
+}
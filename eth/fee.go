@@ -0,0 +1,263 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide fee suggestion and transaction building of legacy and EIP-1559 transactions
+ * @file fee.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// txTypeLegacy sends legacy transactions priced by gasPrice
+	txTypeLegacy = "legacy"
+	// txTypeDynamic sends EIP-1559 transactions priced by maxFeePerGas and maxPriorityFeePerGas
+	txTypeDynamic = "dynamic"
+
+	defaultFeeBlocks     = 10
+	defaultFeePercentile = 50
+	defaultFeeRefresh    = 3 * time.Second
+)
+
+// feeHistory is the response of eth_feeHistory
+type feeHistory struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas"`
+	Reward       [][]*hexutil.Big `json:"reward"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// suggestion returns the base fee of the next block and the median of the sampled priority fees
+func (h *feeHistory) suggestion() (baseFee *big.Int, tip *big.Int, err error) {
+	if len(h.BaseFee) == 0 {
+		return nil, nil, errors.New("empty fee history")
+	}
+	// the last base fee is the one of the next block
+	baseFee = h.BaseFee[len(h.BaseFee)-1].ToInt()
+
+	rewards := make([]*big.Int, 0, len(h.Reward))
+	for _, reward := range h.Reward {
+		if len(reward) > 0 && reward[0] != nil {
+			rewards = append(rewards, reward[0].ToInt())
+		}
+	}
+	if len(rewards) == 0 {
+		return baseFee, new(big.Int), nil
+	}
+	sort.Slice(rewards, func(i, j int) bool {
+		return rewards[i].Cmp(rewards[j]) < 0
+	})
+	return baseFee, rewards[len(rewards)/2], nil
+}
+
+// feeOracle caches the fee suggestion computed from eth_feeHistory
+type feeOracle struct {
+	endpoints  *endpointPool
+	blocks     int
+	percentile float64
+	refresh    time.Duration
+
+	mu      sync.Mutex
+	updated time.Time
+	baseFee *big.Int
+	tip     *big.Int
+}
+
+func newFeeOracle(endpoints *endpointPool, blocks int, percentile float64, refresh time.Duration) *feeOracle {
+	if blocks <= 0 {
+		blocks = defaultFeeBlocks
+	}
+	if percentile <= 0 || percentile > 100 {
+		percentile = defaultFeePercentile
+	}
+	if refresh <= 0 {
+		refresh = defaultFeeRefresh
+	}
+	return &feeOracle{
+		endpoints:  endpoints,
+		blocks:     blocks,
+		percentile: percentile,
+		refresh:    refresh,
+	}
+}
+
+// suggest returns the next base fee and the priority fee,
+// they are refreshed from eth_feeHistory once they are older than refresh
+func (f *feeOracle) suggest() (baseFee *big.Int, tip *big.Int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.baseFee != nil && time.Since(f.updated) < f.refresh {
+		return f.baseFee, f.tip, nil
+	}
+
+	history := &feeHistory{}
+	rpcClient, _ := f.endpoints.clients()
+	err = rpcClient.CallContext(context.Background(), history, "eth_feeHistory", hexutil.Uint(f.blocks), "latest", []float64{f.percentile})
+	if err == nil {
+		baseFee, tip, err = history.suggestion()
+	}
+	if err != nil {
+		// node does not support eth_feeHistory, fall back to the latest header and eth_maxPriorityFeePerGas
+		baseFee, tip, err = f.fallback()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	f.baseFee, f.tip, f.updated = baseFee, tip, time.Now()
+	return baseFee, tip, nil
+}
+
+func (f *feeOracle) fallback() (baseFee *big.Int, tip *big.Int, err error) {
+	_, ethClient := f.endpoints.clients()
+	head, err := ethClient.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if head.BaseFee == nil {
+		return nil, nil, errors.New("london is not active, no base fee in latest header")
+	}
+	tip, err = ethClient.SuggestGasTipCap(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+	return head.BaseFee, tip, nil
+}
+
+// legacyGasPrice returns the gas price set by option `gas` or the one suggested when client created
+func (e *ETH) legacyGasPrice() *big.Int {
+	if e.op.setGas {
+		return e.op.gas
+	}
+	return e.gasPrice
+}
+
+// dynamicFee returns maxFeePerGas and maxPriorityFeePerGas,
+// fixed values set by options are preferred to the ones suggested by fee oracle
+func (e *ETH) dynamicFee() (feeCap *big.Int, tipCap *big.Int, err error) {
+	feeCap, tipCap = e.op.maxFee, e.op.maxPriorityFee
+	if feeCap == nil || tipCap == nil {
+		baseFee, tip, err := e.fee.suggest()
+		if err != nil {
+			return nil, nil, err
+		}
+		if tipCap == nil {
+			tipCap = tip
+		}
+		if feeCap == nil {
+			feeCap = new(big.Int).Add(tipCap, new(big.Int).Mul(baseFee, big.NewInt(2)))
+		}
+	}
+	if feeCap.Cmp(tipCap) < 0 {
+		return nil, nil, errors.New("maxFeePerGas " + feeCap.String() + " < maxPriorityFeePerGas " + tipCap.String())
+	}
+	return feeCap, tipCap, nil
+}
+
+// newTxData builds the transaction of the type used by client, the transaction with access list
+// is an EIP-2930 transaction unless dynamic fee transaction is used
+func (e *ETH) newTxData(nonce uint64, to *common.Address, value *big.Int, gas uint64, data []byte, list types.AccessList) (types.TxData, error) {
+	if e.op.txType == txTypeDynamic {
+		feeCap, tipCap, err := e.dynamicFee()
+		if err != nil {
+			return nil, err
+		}
+		return &types.DynamicFeeTx{
+			ChainID:    e.chainID,
+			Nonce:      nonce,
+			GasTipCap:  tipCap,
+			GasFeeCap:  feeCap,
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: list,
+		}, nil
+	}
+	if list != nil {
+		return &types.AccessListTx{
+			ChainID:    e.chainID,
+			Nonce:      nonce,
+			GasPrice:   e.legacyGasPrice(),
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: list,
+		}, nil
+	}
+	return &types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: e.legacyGasPrice(),
+		Gas:      gas,
+		To:       to,
+		Value:    value,
+		Data:     data,
+	}, nil
+}
+
+// chainSigner returns the signer of chain, the access list transaction is signed by eip-2930 signer before london
+func chainSigner(chainID *big.Int, london bool) types.Signer {
+	if london {
+		return types.NewLondonSigner(chainID)
+	}
+	return types.NewEIP2930Signer(chainID)
+}
+
+// signTx builds a transaction and signs it with the given key
+func (e *ETH) signTx(key *ecdsa.PrivateKey, nonce uint64, to *common.Address, value *big.Int, gas uint64, data []byte) (*types.Transaction, error) {
+	return e.signListTx(key, nonce, to, value, gas, data, nil)
+}
+
+// signListTx builds a transaction with access list and signs it with the given key
+func (e *ETH) signListTx(key *ecdsa.PrivateKey, nonce uint64, to *common.Address, value *big.Int, gas uint64, data []byte, list types.AccessList) (*types.Transaction, error) {
+	if key == nil {
+		return nil, errors.New("no private key to sign transaction")
+	}
+	txData, err := e.newTxData(nonce, to, value, gas, data, list)
+	if err != nil {
+		return nil, err
+	}
+	return types.SignNewTx(key, e.signer, txData)
+}
+
+// applyFee sets the fee fields of transact options according to the transaction type used by client
+func (e *ETH) applyFee(auth *bind.TransactOpts) error {
+	if e.op.txType == txTypeDynamic {
+		feeCap, tipCap, err := e.dynamicFee()
+		if err != nil {
+			return err
+		}
+		auth.GasPrice, auth.GasFeeCap, auth.GasTipCap = nil, feeCap, tipCap
+		return nil
+	}
+	auth.GasPrice, auth.GasFeeCap, auth.GasTipCap = e.legacyGasPrice(), nil, nil
+	return nil
+}
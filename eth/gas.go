@@ -0,0 +1,268 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide the gas limits of transactions by estimation and overrides
+ * @file gas.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	fcom "github.com/hyperbench/hyperbench-common/common"
+)
+
+const (
+	// defaultGasLimit is used when the estimation fails
+	defaultGasLimit = 300000
+	// defaultGasMultiplier is the safety multiplier of estimated gas
+	defaultGasMultiplier = 1.2
+	// gasLimitKey is the key of gas limit in result
+	gasLimitKey = "gasLimit"
+	// deployGasKey is the key of gas limit of deploying contract
+	deployGasKey = "constructor"
+	// transferGasKey is the key of gas limit of transferring
+	transferGasKey = "transfer"
+)
+
+// gasEstimator provides the gas limit of function, the explicit limits are preferred,
+// otherwise the gas estimated on first use is cached by the function signature
+type gasEstimator struct {
+	mu         sync.Mutex
+	multiplier float64
+	// limits are the explicit limits keyed by function name or signature
+	limits map[string]uint64
+	cache  map[string]uint64
+	// codes records whether the recipients of transferring have code
+	codes map[common.Address]bool
+}
+
+func newGasEstimator(multiplier float64, limits map[string]uint64) *gasEstimator {
+	if multiplier <= 0 {
+		multiplier = defaultGasMultiplier
+	}
+	if limits == nil {
+		limits = make(map[string]uint64)
+	}
+	return &gasEstimator{
+		multiplier: multiplier,
+		limits:     limits,
+		cache:      make(map[string]uint64),
+		codes:      make(map[common.Address]bool),
+	}
+}
+
+// parseGasLimits parses the gas limits in form of `function=limit`
+func parseGasLimits(entries []string) (map[string]uint64, error) {
+	limits := make(map[string]uint64, len(entries))
+	for _, entry := range entries {
+		i := strings.LastIndex(entry, "=")
+		if i < 0 {
+			return nil, errors.New("invalid gas limit: " + entry)
+		}
+		limit, err := strconv.ParseUint(strings.TrimSpace(entry[i+1:]), 10, 64)
+		if err != nil || limit == 0 {
+			return nil, errors.New("invalid gas limit: " + entry)
+		}
+		limits[strings.TrimSpace(entry[:i])] = limit
+	}
+	return limits, nil
+}
+
+// parseGasLimitOption parses the option `gaslimit`, a number is the limit of all functions
+// and a table maps function name or signature to its limit
+func parseGasLimitOption(value interface{}) (map[string]uint64, error) {
+	switch v := value.(type) {
+	case float64:
+		if v < 1 {
+			return nil, fmt.Errorf("option `gaslimit` error: invalid limit %v", v)
+		}
+		return map[string]uint64{"": uint64(v)}, nil
+	case map[interface{}]interface{}:
+		limits := make(map[string]uint64, len(v))
+		for k, l := range v {
+			name, ok := k.(string)
+			limit, isNum := l.(float64)
+			if !ok || !isNum || limit < 1 {
+				return nil, fmt.Errorf("option `gaslimit` error: invalid limit of %v: %v", k, l)
+			}
+			limits[name] = uint64(limit)
+		}
+		return limits, nil
+	default:
+		return nil, errors.New("option `gaslimit` type error: " + reflect.TypeOf(value).Name())
+	}
+}
+
+// limitOf returns the explicit limit of function by its keys, the first found is used
+func limitOf(limits map[string]uint64, keys ...string) (uint64, bool) {
+	for _, key := range keys {
+		if limit, ok := limits[key]; ok {
+			return limit, true
+		}
+	}
+	return 0, false
+}
+
+// explicitGasLimit returns the gas limit set for the function of key or sig,
+// the limit of option is preferred to the one of eth.toml
+func (e *ETH) explicitGasLimit(key, sig string) (uint64, bool) {
+	if limit, ok := limitOf(e.op.gasLimits, key, sig, ""); ok {
+		return limit, true
+	}
+	return limitOf(e.gas.limits, key, sig)
+}
+
+// estimateGas estimates the gas of message with the safety multiplier,
+// the default limit is returned if the estimation fails
+func (e *ETH) estimateGas(sig string, msg ethereum.CallMsg) (uint64, bool) {
+	var estimated uint64
+	_, err := e.endpoints.call(func(ep *endpoint) (err error) {
+		estimated, err = ep.ethClient.EstimateGas(context.Background(), msg)
+		return err
+	})
+	if err != nil {
+		// the estimation fails if the call reverts
+		e.Logger.Debugf("estimate gas of %v failed: %v", sig, err)
+		return defaultGasLimit, false
+	}
+	limit := uint64(float64(estimated) * e.gas.multiplier)
+	e.Logger.Debugf("gas limit of %v is %v, estimated %v", sig, limit, estimated)
+	return limit, true
+}
+
+// gasLimit returns the gas limit of transaction calling the function of key, sig is the signature of function.
+// The explicit limit is preferred, otherwise the estimation is cached by sig, it is estimated again next time if it fails.
+func (e *ETH) gasLimit(key, sig string, msg ethereum.CallMsg) uint64 {
+	if limit, ok := e.explicitGasLimit(key, sig); ok {
+		return limit
+	}
+	g := e.gas
+	g.mu.Lock()
+	limit, ok := g.cache[sig]
+	g.mu.Unlock()
+	if ok {
+		return limit
+	}
+	if limit, ok = e.estimateGas(sig, msg); ok {
+		g.mu.Lock()
+		g.cache[sig] = limit
+		g.mu.Unlock()
+	}
+	return limit
+}
+
+// deployGasLimit returns the gas limit of creating contract by data,
+// it is not cached since the libraries and the contract differ
+func (e *ETH) deployGasLimit(data []byte) uint64 {
+	if limit, ok := e.explicitGasLimit(deployGasKey, deployGasKey); ok {
+		return limit
+	}
+	limit, _ := e.estimateGas(deployGasKey, ethereum.CallMsg{From: fromAddress, Value: big.NewInt(0), Data: data})
+	return limit
+}
+
+// invokeGasLimit returns the gas limit of invoking the method of contract at address to,
+// to is nil if the contract is created
+func (e *ETH) invokeGasLimit(from common.Address, to *common.Address, method, sig string, data []byte) uint64 {
+	return e.gasLimit(method, sig, ethereum.CallMsg{
+		From:  from,
+		To:    to,
+		Value: big.NewInt(0),
+		Data:  data,
+	})
+}
+
+// transferGasLimit returns the gas limit of transferring. The estimation of transferring to accounts without code
+// is shared, the one to contract is cached by the recipient, and the one with data is estimated every time
+func (e *ETH) transferGasLimit(from common.Address, to *common.Address, value *big.Int, data []byte) uint64 {
+	msg := ethereum.CallMsg{
+		From:  from,
+		To:    to,
+		Value: value,
+		Data:  data,
+	}
+	if limit, ok := e.explicitGasLimit(transferGasKey, fcom.BuiltinTransferLabel); ok {
+		return limit
+	}
+	if len(data) > 0 {
+		limit, _ := e.estimateGas(fcom.BuiltinTransferLabel, msg)
+		return limit
+	}
+	sig := fcom.BuiltinTransferLabel
+	if to != nil && e.hasCode(*to) {
+		sig += ":" + to.Hex()
+	}
+	return e.gasLimit(transferGasKey, sig, msg)
+}
+
+// hasCode returns whether there is code at address, it is cached for the recipients of transferring.
+// The address is regarded as a contract if the code fails to be queried
+func (e *ETH) hasCode(address common.Address) bool {
+	g := e.gas
+	g.mu.Lock()
+	code, ok := g.codes[address]
+	g.mu.Unlock()
+	if ok {
+		return code
+	}
+	var data []byte
+	_, err := e.endpoints.call(func(ep *endpoint) (err error) {
+		data, err = ep.ethClient.CodeAt(context.Background(), address, nil)
+		return err
+	})
+	if err != nil {
+		return true
+	}
+	g.mu.Lock()
+	g.codes[address] = len(data) > 0
+	g.mu.Unlock()
+	return len(data) > 0
+}
+
+// withGasLimit records the gas limit in the values of result
+func withGasLimit(ret []interface{}, gas uint64) []interface{} {
+	for _, value := range ret {
+		if m, ok := value.(map[string]interface{}); ok {
+			m[gasLimitKey] = gas
+			return ret
+		}
+	}
+	return append(ret, map[string]interface{}{gasLimitKey: gas})
+}
+
+// resultGasLimit returns the gas limit recorded in result
+func resultGasLimit(result *fcom.Result) (uint64, bool) {
+	for _, value := range result.Ret {
+		if m, ok := value.(map[string]interface{}); ok {
+			if gas, ok := m[gasLimitKey].(uint64); ok {
+				return gas, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+	fcom "github.com/hyperbench/hyperbench-common/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGasLimits(t *testing.T) {
+	limits, err := parseGasLimits([]string{"set(uint256) = 80000", "transfer=21000"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"set(uint256)": 80000, "transfer": 21000}, limits)
+	_, err = parseGasLimits([]string{"set"})
+	assert.Error(t, err)
+	_, err = parseGasLimits([]string{"set=0"})
+	assert.Error(t, err)
+
+	limits, err = parseGasLimitOption(float64(50000))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"": 50000}, limits)
+	limits, err = parseGasLimitOption(map[interface{}]interface{}{"set": float64(60000)})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint64{"set": 60000}, limits)
+	_, err = parseGasLimitOption(map[interface{}]interface{}{"set": "a lot"})
+	assert.Error(t, err)
+	_, err = parseGasLimitOption("a lot")
+	assert.Error(t, err)
+}
+
+func TestGasLimit(t *testing.T) {
+	client, stop := newSimulatedClient(t, storageABI, storageBIN)
+	defer stop()
+	assert.NoError(t, client.DeployContract())
+
+	// the estimation is cached by signature with the multiplier
+	res := client.Invoke(fcom.Invoke{Func: "set", Args: []interface{}{float64(42)}})
+	assert.Equal(t, fcom.Success, res.Status)
+	gas, ok := resultGasLimit(res)
+	assert.True(t, ok)
+	assert.Equal(t, gas, client.gas.cache["set(uint256)"])
+	assert.True(t, gas > params.TxGas && gas < defaultGasLimit)
+	res = client.Confirm(res)
+	assert.Equal(t, fcom.Confirm, res.Status)
+	info := res.Ret[0].(map[string]interface{})
+	assert.Equal(t, gas, info[gasLimitKey])
+	assert.True(t, info["gasUsed"].(uint64) < gas)
+
+	other := accountAlias(accounts["other"])
+	res = client.Transfer(fcom.Transfer{From: "main", To: other, Amount: 1})
+	gas, _ = resultGasLimit(res)
+	assert.Equal(t, uint64(float64(params.TxGas)*defaultGasMultiplier), gas)
+	assert.Equal(t, fcom.Confirm, client.Confirm(res).Status)
+
+	// the transferring with data or to contract does not share the estimation of plain transferring
+	res = client.Transfer(fcom.Transfer{From: "main", To: other, Amount: 1, Extra: "a transfer with data"})
+	gas, _ = resultGasLimit(res)
+	assert.True(t, gas > uint64(float64(params.TxGas)*defaultGasMultiplier))
+	assert.Equal(t, fcom.Confirm, client.Confirm(res).Status)
+	res = client.Transfer(fcom.Transfer{From: "main", To: client.contract.Address.Hex(), Amount: 1})
+	gas, _ = resultGasLimit(res)
+	assert.True(t, gas > uint64(float64(params.TxGas)*defaultGasMultiplier))
+	assert.True(t, client.gas.codes[client.contract.Address])
+	assert.Equal(t, uint64(float64(params.TxGas)*defaultGasMultiplier), client.gas.cache[fcom.BuiltinTransferLabel])
+
+	// the limits of eth.toml are overridden by option
+	client.gas.limits["transfer"] = 30000
+	res = client.Transfer(fcom.Transfer{From: "main", To: other, Amount: 1})
+	gas, _ = resultGasLimit(res)
+	assert.Equal(t, uint64(30000), gas)
+	assert.NoError(t, client.Option(fcom.Option{"gaslimit": map[interface{}]interface{}{"set(uint256)": float64(70000)}}))
+	res = client.Invoke(fcom.Invoke{Func: "set", Args: []interface{}{float64(7)}})
+	gas, _ = resultGasLimit(res)
+	assert.Equal(t, uint64(70000), gas)
+	assert.NoError(t, client.Option(fcom.Option{"gaslimit": float64(40000)}))
+	res = client.Transfer(fcom.Transfer{From: "main", To: other, Amount: 1})
+	gas, _ = resultGasLimit(res)
+	assert.Equal(t, uint64(40000), gas)
+}
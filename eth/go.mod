@@ -8,13 +8,16 @@ require (
 	github.com/hyperbench/hyperbench-common v0.0.4
 	github.com/jackpal/go-nat-pmp v1.0.2 // indirect
 	github.com/magiconair/properties v1.8.6 // indirect
+	github.com/op/go-logging v0.0.0-20160315200505-970db520ece7
 	github.com/spf13/afero v1.8.2 // indirect
 	github.com/spf13/cast v1.5.0
 	github.com/spf13/viper v1.10.1
 	github.com/stretchr/testify v1.8.0
-	golang.org/x/crypto v0.0.0 // indirect
+	github.com/tyler-smith/go-bip39 v1.0.1-0.20181017060643-dbb3b84ba2ef
+	golang.org/x/crypto v0.0.0
 	golang.org/x/net v0.0.0-20220722155237-a158d28d115b // indirect
 	golang.org/x/sys v0.0.0 // indirect
+	golang.org/x/text v0.3.7
 	google.golang.org/protobuf v1.28.0 // indirect
 )
 
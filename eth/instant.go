@@ -0,0 +1,188 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide the instant accounts created and funded by master for workers
+ * @file instant.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// defaultInstantFund is the wei funded to each instant account
+	defaultInstantFund = params.Ether
+	// instantBatch is the number of funding transactions sent by one batch request
+	instantBatch = 100
+)
+
+// parseInstantFund parses the client option `instantfund`, a number or a decimal or hex string of wei
+func parseInstantFund(value interface{}) (*big.Int, error) {
+	if value == nil {
+		return big.NewInt(defaultInstantFund), nil
+	}
+	fund, err := parseUint(value)
+	if err != nil {
+		return nil, errors.New("option `instantfund` type error: " + reflect.TypeOf(value).Name())
+	}
+	return fund, nil
+}
+
+// createInstants generates count accounts and funds them from the main account by batch requests,
+// it returns after all funding transactions are confirmed
+func (e *ETH) createInstants(count int, fund *big.Int) (map[string]*ecdsa.PrivateKey, error) {
+	instants := make(map[string]*ecdsa.PrivateKey, count)
+	var hashes []common.Hash
+	for start := 0; start < count; start += instantBatch {
+		end := start + instantBatch
+		if end > count {
+			end = count
+		}
+		keys := make([]*ecdsa.PrivateKey, 0, end-start)
+		txs := make([]*types.Transaction, 0, end-start)
+		for i := start; i < end; i++ {
+			key, err := crypto.GenerateKey()
+			if err != nil {
+				return nil, err
+			}
+			to := crypto.PubkeyToAddress(key.PublicKey)
+			tx, err := e.signMain(&to, fund, params.TxGas, nil)
+			if err != nil {
+				return nil, err
+			}
+			keys, txs = append(keys, key), append(txs, tx)
+		}
+		for i, err := range e.sendBatch(txs) {
+			if err != nil {
+				e.Logger.Errorf("fund instant account failed: %v", err)
+				continue
+			}
+			instants[accountAlias(keys[i])] = keys[i]
+			hashes = append(hashes, txs[i].Hash())
+		}
+	}
+
+	if err := e.waitTxs(hashes); err != nil {
+		return nil, fmt.Errorf("fund instant accounts: %v", err)
+	}
+	e.Logger.Noticef("%v instant accounts are funded with %v wei", len(instants), fund)
+	return instants, nil
+}
+
+// signMain signs the transaction of main account with the next nonce,
+// the nonce is reported to nonce manager when the transaction is sent by sendBatch
+func (e *ETH) signMain(to *common.Address, value *big.Int, gas uint64, data []byte) (*types.Transaction, error) {
+	nonce, err := e.nonces.next(e.mainSender.address)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := e.signTx(e.mainSender.key, nonce, to, value, gas, data)
+	if err != nil {
+		e.nonces.commit(e.mainSender.address, nonce, err)
+		return nil, err
+	}
+	return tx, nil
+}
+
+// sendBatch sends the transactions of main account by one batch request,
+// the errors of sending are returned in order
+func (e *ETH) sendBatch(txs []*types.Transaction) []error {
+	errs := make([]error, len(txs))
+	batch := make([]rpc.BatchElem, len(txs))
+	for i, tx := range txs {
+		data, err := tx.MarshalBinary()
+		errs[i] = err
+		batch[i] = rpc.BatchElem{
+			Method: "eth_sendRawTransaction",
+			Args:   []interface{}{hexutil.Encode(data)},
+			Result: new(common.Hash),
+		}
+	}
+	_, err := e.endpoints.call(func(ep *endpoint) error {
+		return ep.rpcClient.BatchCallContext(context.Background(), batch)
+	})
+	for i, elem := range batch {
+		if errs[i] == nil {
+			errs[i] = err
+		}
+		if errs[i] == nil {
+			errs[i] = elem.Error
+		}
+		e.nonces.commit(e.mainSender.address, txs[i].Nonce(), errs[i])
+	}
+	return errs
+}
+
+// waitTxs waits for the transactions confirmed, an error is returned if any of them fails
+func (e *ETH) waitTxs(hashes []common.Hash) error {
+	for _, hash := range hashes {
+		r, _, err := e.confirmReceipt(hash)
+		if err != nil {
+			return fmt.Errorf("confirm transaction %v failed: %v", hash.Hex(), err)
+		}
+		if r.Status != types.ReceiptStatusSuccessful {
+			return fmt.Errorf("transaction %v failed", hash.Hex())
+		}
+	}
+	return nil
+}
+
+// useInstants uses the instant accounts as the senders of vm, each vm owns disjoint accounts if there are enough
+func (e *ETH) useInstants(instants map[string]*ecdsa.PrivateKey) {
+	if len(instants) == 0 {
+		return
+	}
+	pool := newSenderPool(instants, fromAddress, e.vmIndex, e.vmCount)
+	for _, s := range e.senders.byAlias {
+		if _, ok := pool.byAlias[s.alias]; !ok {
+			pool.add(s)
+		}
+	}
+	// the keystore accounts owned by other vms are still owned by them
+	for address := range e.senders.others {
+		pool.others[address] = true
+	}
+	e.senders = pool
+	// the accounts loaded from keystore are shared by vms, they are copied before adding
+	all := make(map[string]*ecdsa.PrivateKey, len(e.Accounts)+len(instants))
+	for alias, key := range e.Accounts {
+		all[alias] = key
+	}
+	for alias, key := range instants {
+		all[alias] = key
+	}
+	e.Accounts = all
+	e.ownSenders(pool)
+	// instant accounts are used in turn unless an account is chosen
+	if e.op.sender == senderFixed && e.op.account == "" {
+		e.op.sender = senderRoundRobin
+	}
+}
@@ -0,0 +1,373 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Manage the nonces of accounts and recover from nonce gaps
+ * @file nonce.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/op/go-logging"
+)
+
+const defaultNonceCheck = 5 * time.Second
+
+// nonceError is the kind of error returned when sending a transaction
+type nonceError int
+
+const (
+	// nonceOK means the transaction is accepted by node
+	nonceOK nonceError = iota
+	// nonceUsed means the nonce is used by another transaction
+	nonceUsed
+	// nonceFuture means there is a gap before the nonce
+	nonceFuture
+	// nonceUnsent means the transaction is not accepted and the nonce can be reused
+	nonceUnsent
+)
+
+// classifyNonceError classifies the error returned by node when sending transaction
+func classifyNonceError(err error) nonceError {
+	if err == nil {
+		return nonceOK
+	}
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "already known"), strings.Contains(msg, "known transaction"):
+		// the same transaction is in txpool
+		return nonceOK
+	case strings.Contains(msg, "nonce too low"), strings.Contains(msg, "replacement transaction underpriced"):
+		return nonceUsed
+	case strings.Contains(msg, "nonce too high"):
+		return nonceFuture
+	default:
+		return nonceUnsent
+	}
+}
+
+// nonceSource provides the pending nonce of account
+type nonceSource interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// nonceFiller fills the nonce gap of account, usually by a zero-value self-transfer
+type nonceFiller func(account common.Address, nonce uint64) error
+
+// nonceAccount is the nonce state of an account
+type nonceAccount struct {
+	// base is the first nonce of account when test begins
+	base uint64
+	// stride and offset partition the nonces of account among vms
+	stride uint64
+	offset uint64
+	// round is the next round of nonce partition
+	round uint64
+	// released are the nonces allocated but not sent, they are reused first
+	released []uint64
+	// sent is the highest nonce sent plus one
+	sent uint64
+	// pending is the pending nonce of node in last check
+	pending uint64
+	// checked is the time of last check
+	checked time.Time
+}
+
+// nonceManager allocates the nonces of accounts.
+// The nonces of an account shared by vms are partitioned among vms, the vm with offset `o` owns
+// the nonces `base+o`, `base+o+stride`, `base+o+2*stride` and so on.
+// The nonces of an account exclusively owned by a vm are allocated one by one.
+type nonceManager struct {
+	source   nonceSource
+	fill     nonceFiller
+	stride   uint64
+	offset   uint64
+	interval time.Duration
+	logger   *logging.Logger
+
+	mu        sync.Mutex
+	accounts  map[common.Address]*nonceAccount
+	exclusive map[common.Address]bool
+}
+
+// newNonceManager creates nonce manager, fill is nil if nonce gaps should not be filled
+func newNonceManager(source nonceSource, fill nonceFiller, stride, offset uint64, interval time.Duration, logger *logging.Logger) *nonceManager {
+	if stride == 0 {
+		stride = 1
+	}
+	if interval <= 0 {
+		interval = defaultNonceCheck
+	}
+	return &nonceManager{
+		source:    source,
+		fill:      fill,
+		stride:    stride,
+		offset:    offset % stride,
+		interval:  interval,
+		logger:    logger,
+		accounts:  make(map[common.Address]*nonceAccount),
+		exclusive: make(map[common.Address]bool),
+	}
+}
+
+// own marks the account as exclusively owned by this vm
+func (m *nonceManager) own(account common.Address) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exclusive[account] = true
+	if acc, ok := m.accounts[account]; ok {
+		acc.stride, acc.offset = 1, 0
+	}
+}
+
+// newAccount creates the nonce state of account beginning with base
+func (m *nonceManager) newAccount(account common.Address, base uint64) *nonceAccount {
+	acc := &nonceAccount{
+		base:    base,
+		stride:  m.stride,
+		offset:  m.offset,
+		pending: base,
+		checked: time.Now(),
+	}
+	if m.exclusive[account] {
+		acc.stride, acc.offset = 1, 0
+	}
+	m.accounts[account] = acc
+	return acc
+}
+
+// setBase sets the first nonce of account, usually it is sent by master in context
+func (m *nonceManager) setBase(account common.Address, base uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.newAccount(account, base)
+}
+
+// account returns the nonce state of account, it is initiated by the pending nonce of node
+func (m *nonceManager) account(account common.Address) (*nonceAccount, error) {
+	if acc, ok := m.accounts[account]; ok {
+		return acc, nil
+	}
+	pending, err := m.source.PendingNonceAt(context.Background(), account)
+	if err != nil {
+		return nil, err
+	}
+	return m.newAccount(account, pending), nil
+}
+
+// next allocates the next nonce of account, the nonce gap found by the check is filled after unlocking
+func (m *nonceManager) next(account common.Address) (uint64, error) {
+	m.mu.Lock()
+	nonce, gap, fill, err := m.allocate(account)
+	m.mu.Unlock()
+	if fill {
+		m.fillGap(account, gap)
+	}
+	return nonce, err
+}
+
+// allocate allocates the next nonce of account, the nonce gap to fill is returned if the check finds one
+func (m *nonceManager) allocate(account common.Address) (nonce, gap uint64, fill bool, err error) {
+	acc, err := m.account(account)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if time.Since(acc.checked) >= m.interval {
+		gap, fill = m.check(account, acc)
+	}
+	if len(acc.released) > 0 {
+		nonce = acc.released[0]
+		acc.released = acc.released[1:]
+		return nonce, gap, fill, nil
+	}
+	nonce = acc.base + acc.round*acc.stride + acc.offset
+	acc.round++
+	return nonce, gap, fill, nil
+}
+
+// commit reports the result of sending the transaction with nonce
+func (m *nonceManager) commit(account common.Address, nonce uint64, err error) {
+	m.mu.Lock()
+	acc, ok := m.accounts[account]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	var (
+		gap  uint64
+		fill bool
+	)
+	switch classifyNonceError(err) {
+	case nonceOK:
+		if nonce+1 > acc.sent {
+			acc.sent = nonce + 1
+		}
+	case nonceUsed:
+		m.logger.Noticef("nonce %v of %v is used, resync: %v", nonce, account.Hex(), err)
+		m.resync(account, acc)
+	case nonceFuture:
+		m.logger.Noticef("nonce %v of %v is too high, check gap: %v", nonce, account.Hex(), err)
+		m.release(acc, nonce)
+		gap, fill = m.check(account, acc)
+	default:
+		m.release(acc, nonce)
+	}
+	m.mu.Unlock()
+	if fill {
+		m.fillGap(account, gap)
+	}
+}
+
+// release puts back the nonce which is not sent
+func (m *nonceManager) release(acc *nonceAccount, nonce uint64) {
+	acc.released = append(acc.released, nonce)
+	sort.Slice(acc.released, func(i, j int) bool {
+		return acc.released[i] < acc.released[j]
+	})
+}
+
+// resync drops the nonces lower than the pending nonce of node
+// and moves the partition forward to the pending nonce
+func (m *nonceManager) resync(account common.Address, acc *nonceAccount) {
+	pending, err := m.source.PendingNonceAt(context.Background(), account)
+	if err != nil {
+		m.logger.Errorf("resync nonce of %v failed: %v", account.Hex(), err)
+		return
+	}
+	m.skip(acc, pending)
+	acc.pending, acc.checked = pending, time.Now()
+}
+
+// skip drops the nonces lower than pending
+func (m *nonceManager) skip(acc *nonceAccount, pending uint64) {
+	released := acc.released[:0]
+	for _, nonce := range acc.released {
+		if nonce >= pending {
+			released = append(released, nonce)
+		}
+	}
+	acc.released = released
+	first := acc.base + acc.offset
+	if next := first + acc.round*acc.stride; next < pending {
+		acc.round = (pending - first + acc.stride - 1) / acc.stride
+	}
+}
+
+// advance moves the nonces of account forward to next, the lower nonces are
+// used by transactions signed outside the manager such as the loaded corpus
+func (m *nonceManager) advance(account common.Address, next uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	acc, err := m.account(account)
+	if err != nil {
+		return err
+	}
+	m.skip(acc, next)
+	return nil
+}
+
+// check detects the nonce gap of account. If the pending nonce of node
+// does not move since last check while higher nonces were sent,
+// the transaction with pending nonce is missing. The gap is returned to fill
+// if filler is provided, or the nonce is reused if it is owned by this vm.
+func (m *nonceManager) check(account common.Address, acc *nonceAccount) (uint64, bool) {
+	pending, err := m.source.PendingNonceAt(context.Background(), account)
+	if err != nil {
+		m.logger.Errorf("check nonce of %v failed: %v", account.Hex(), err)
+		return 0, false
+	}
+	stalled := pending == acc.pending && pending < acc.sent
+	acc.pending, acc.checked = pending, time.Now()
+	m.skip(acc, pending)
+	if !stalled {
+		return 0, false
+	}
+
+	m.logger.Noticef("nonce gap of %v detected at %v", account.Hex(), pending)
+	if m.fill != nil {
+		return pending, true
+	}
+	m.reuse(acc, pending)
+	return 0, false
+}
+
+// fillGap fills the nonce gap of account by filler without holding the lock,
+// the nonce is reused if it is owned by this vm when filling fails
+func (m *nonceManager) fillGap(account common.Address, nonce uint64) {
+	err := m.fill(account, nonce)
+	if classifyNonceError(err) == nonceOK {
+		return
+	}
+	m.logger.Errorf("fill nonce gap of %v at %v failed: %v", account.Hex(), nonce, err)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if acc, ok := m.accounts[account]; ok {
+		m.reuse(acc, nonce)
+	}
+}
+
+// reuse releases the nonce of gap to be sent again if it is owned by this vm and not released yet
+func (m *nonceManager) reuse(acc *nonceAccount, nonce uint64) {
+	if nonce < acc.base+acc.offset || (nonce-acc.base-acc.offset)%acc.stride != 0 {
+		return
+	}
+	for _, released := range acc.released {
+		if released == nonce {
+			return
+		}
+	}
+	m.release(acc, nonce)
+}
+
+// bases returns the pending nonces of accounts, they are sent to workers in context
+func (m *nonceManager) bases(accounts []common.Address) (map[string]uint64, error) {
+	bases := make(map[string]uint64, len(accounts))
+	for _, account := range accounts {
+		pending, err := m.source.PendingNonceAt(context.Background(), account)
+		if err != nil {
+			return nil, err
+		}
+		bases[account.Hex()] = pending
+	}
+	return bases, nil
+}
+
+// fillNonceGap sends a zero-value self-transfer with the missing nonce
+func (e *ETH) fillNonceGap(account common.Address, nonce uint64) error {
+	s, ok := e.senders.byAddress[account]
+	if !ok {
+		return errors.New("no private key of account " + account.Hex())
+	}
+	tx, err := e.signTx(s.key, nonce, &account, big.NewInt(0), params.TxGas, nil)
+	if err != nil {
+		return err
+	}
+	_, err = e.endpoints.call(func(ep *endpoint) error {
+		return ep.ethClient.SendTransaction(context.Background(), tx)
+	})
+	return err
+}
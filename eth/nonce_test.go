@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	fcom "github.com/hyperbench/hyperbench-common/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeNonceSource struct {
+	pending uint64
+	err     error
+}
+
+func (f *fakeNonceSource) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return f.pending, f.err
+}
+
+func TestNonceManager(t *testing.T) {
+	account := common.HexToAddress("0x74d366e0649a91395bb122c005917644382b9452")
+	source := &fakeNonceSource{pending: 10}
+	m := newNonceManager(source, nil, 4, 1, 0, fcom.GetLogger("eth"))
+
+	// partition
+	nonce, err := m.next(account)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(11), nonce)
+	m.commit(account, nonce, nil)
+	nonce, _ = m.next(account)
+	assert.Equal(t, uint64(15), nonce)
+
+	// failed nonce is reused
+	m.commit(account, nonce, errors.New("connection refused"))
+	nonce, _ = m.next(account)
+	assert.Equal(t, uint64(15), nonce)
+	m.commit(account, nonce, errors.New("already known"))
+
+	// resync when nonce is used
+	source.pending = 30
+	nonce, _ = m.next(account)
+	assert.Equal(t, uint64(19), nonce)
+	m.commit(account, nonce, errors.New("nonce too low"))
+	nonce, _ = m.next(account)
+	assert.Equal(t, uint64(31), nonce)
+
+	// base from context
+	m.setBase(account, 100)
+	nonce, _ = m.next(account)
+	assert.Equal(t, uint64(101), nonce)
+
+	source.err = errors.New("connection refused")
+	_, err = m.next(common.HexToAddress("0x3b2b643246666bfa1332257c13d0d1283736838d"))
+	assert.Error(t, err)
+}
+
+func TestNonceGap(t *testing.T) {
+	account := common.HexToAddress("0x74d366e0649a91395bb122c005917644382b9452")
+	source := &fakeNonceSource{pending: 0}
+	m := newNonceManager(source, nil, 2, 0, 0, fcom.GetLogger("eth"))
+	for i := 0; i < 3; i++ {
+		nonce, _ := m.next(account)
+		m.commit(account, nonce, nil)
+	}
+	// pending nonce does not move while nonces up to 4 were sent, nonce 0 is reused
+	m.check(account, m.accounts[account])
+	nonce, _ := m.next(account)
+	assert.Equal(t, uint64(0), nonce)
+
+	// nonce 2 is missing and filled by the next allocation after unlocking
+	var filled []uint64
+	m.fill = func(account common.Address, nonce uint64) error {
+		assert.True(t, m.mu.TryLock())
+		m.mu.Unlock()
+		filled = append(filled, nonce)
+		return nil
+	}
+	source.pending = 2
+	_, fill := m.check(account, m.accounts[account])
+	assert.False(t, fill)
+	m.interval = time.Nanosecond
+	nonce, _ = m.next(account)
+	assert.Equal(t, []uint64{2}, filled)
+	assert.Equal(t, uint64(6), nonce)
+	m.interval = time.Hour
+
+	// nonce too high
+	m.fill = nil
+	m.commit(account, nonce, errors.New("nonce too high"))
+	nonce, _ = m.next(account)
+	assert.Equal(t, uint64(2), nonce)
+	nonce, _ = m.next(account)
+	assert.Equal(t, uint64(6), nonce)
+
+	assert.Equal(t, nonceUsed, classifyNonceError(errors.New("replacement transaction underpriced")))
+	bases, err := m.bases([]common.Address{account})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), bases[account.Hex()])
+}
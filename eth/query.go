@@ -0,0 +1,209 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide queries of contract view functions and chain state
+ * @file query.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	fcom "github.com/hyperbench/hyperbench-common/common"
+)
+
+// built-in queries of chain state
+const (
+	// BuiltinBalanceLabel queries the balance of address, args: address
+	BuiltinBalanceLabel = "__balance"
+	// BuiltinNonceLabel queries the nonce of address, args: address
+	BuiltinNonceLabel = "__nonce"
+	// BuiltinCodeLabel queries the code of address, args: address
+	BuiltinCodeLabel = "__code"
+	// BuiltinStorageLabel queries the storage slot of address, args: address, slot
+	BuiltinStorageLabel = "__storage"
+	// BuiltinStatusLabel queries the status of node, no args
+	BuiltinStatusLabel = "__status"
+)
+
+// blockOption is the query option to choose the block number,
+// its value can be a number, `latest` or `pending`
+const blockOption = "block"
+
+// Query calls the view or pure function of contract, or queries the chain state by built-in functions.
+// The block can be chosen by query option `block`, the latest block is used by default.
+// Results are returned as plain values, nil is returned if query failed.
+func (e *ETH) Query(query fcom.Query, ops ...fcom.Option) interface{} {
+	block, pending, err := parseBlockOption(ops...)
+	if err != nil {
+		e.Logger.Errorf("query %v error: %v", query.Func, err)
+		return nil
+	}
+
+	var ret interface{}
+	switch query.Func {
+	case BuiltinBalanceLabel, BuiltinNonceLabel, BuiltinCodeLabel, BuiltinStorageLabel:
+		ret, err = e.queryState(query, block, pending)
+	case BuiltinStatusLabel:
+		var status *nodeStatus
+		if status, err = queryNodeStatus(e.rpcClient()); err == nil {
+			ret = status.info()
+		}
+	default:
+		ret, err = e.queryContract(query, block, pending)
+	}
+	if err != nil {
+		e.Logger.Errorf("query %v error: %v", query.Func, err)
+		return nil
+	}
+	return ret
+}
+
+// queryContract calls the function of contract and decodes the outputs by abi
+func (e *ETH) queryContract(query fcom.Query, block *big.Int, pending bool) (interface{}, error) {
+	if e.contract == nil {
+		return nil, errors.New("no contract to query")
+	}
+	c, method, err := e.route(query.Func)
+	if err != nil {
+		return nil, err
+	}
+	args, err := abiArgs(method.Inputs, query.Args)
+	if err != nil {
+		return nil, err
+	}
+	instance := bind.NewBoundContract(c.Address, c.parsedAbi, e.ethClient(), e.ethClient(), e.ethClient())
+	var out []interface{}
+	opts := &bind.CallOpts{
+		Pending:     pending,
+		From:        fromAddress,
+		BlockNumber: block,
+	}
+	err = instance.Call(opts, &out, method.Name, args...)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]interface{}, len(out))
+	for i, o := range out {
+		ret[i] = plainValue(o)
+	}
+	return ret, nil
+}
+
+// queryState queries balance, nonce, code or storage slot of address
+func (e *ETH) queryState(query fcom.Query, block *big.Int, pending bool) (interface{}, error) {
+	if len(query.Args) == 0 {
+		return nil, errors.New("address is required")
+	}
+	address, ok := query.Args[0].(string)
+	if !ok || !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid address: %v", query.Args[0])
+	}
+	account := common.HexToAddress(address)
+	ctx := context.Background()
+
+	switch query.Func {
+	case BuiltinBalanceLabel:
+		var balance *big.Int
+		var err error
+		if pending {
+			balance, err = e.ethClient().PendingBalanceAt(ctx, account)
+		} else {
+			balance, err = e.ethClient().BalanceAt(ctx, account, block)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return balance.String(), nil
+	case BuiltinNonceLabel:
+		if pending {
+			return e.ethClient().PendingNonceAt(ctx, account)
+		}
+		return e.ethClient().NonceAt(ctx, account, block)
+	case BuiltinCodeLabel:
+		var code []byte
+		var err error
+		if pending {
+			code, err = e.ethClient().PendingCodeAt(ctx, account)
+		} else {
+			code, err = e.ethClient().CodeAt(ctx, account, block)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return hexutil.Encode(code), nil
+	default:
+		if len(query.Args) < 2 {
+			return nil, errors.New("storage slot is required")
+		}
+		slot, err := parseUint(query.Args[1])
+		if err != nil {
+			return nil, err
+		}
+		key := common.BigToHash(slot)
+		var value []byte
+		if pending {
+			value, err = e.ethClient().PendingStorageAt(ctx, account, key)
+		} else {
+			value, err = e.ethClient().StorageAt(ctx, account, key, block)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return hexutil.Encode(value), nil
+	}
+}
+
+// parseBlockOption returns the block number chosen by option `block`,
+// nil block means the latest block
+func parseBlockOption(ops ...fcom.Option) (block *big.Int, pending bool, err error) {
+	for _, op := range ops {
+		value, ok := op[blockOption]
+		if !ok {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			switch strings.ToLower(v) {
+			case "latest":
+				block, pending = nil, false
+				continue
+			case "pending":
+				block, pending = nil, true
+				continue
+			}
+		case float64:
+		default:
+			return nil, false, errors.New("option `block` type error: " + reflect.TypeOf(value).Name())
+		}
+		block, err = parseUint(value)
+		if err != nil {
+			return nil, false, err
+		}
+		pending = false
+	}
+	return block, pending, nil
+}
@@ -0,0 +1,176 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide receipt polling and block header lookup for confirmation
+ * @file receipt.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	defaultConfirmTimeout  = 30 * time.Second
+	defaultConfirmInterval = 200 * time.Millisecond
+	// headerCacheSize is the number of block headers kept for confirmation
+	headerCacheSize = 128
+)
+
+// errReceiptTimeout is returned when receipt is not found before confirm timeout
+var errReceiptTimeout = errors.New("wait for receipt timeout")
+
+// receipt is the transaction receipt with the effective gas price
+type receipt struct {
+	*types.Receipt
+	EffectiveGasPrice *big.Int
+}
+
+// headerCache caches the recent block headers by hash,
+// transactions included in the same block share one lookup
+type headerCache struct {
+	mu      sync.Mutex
+	headers map[common.Hash]*types.Header
+	order   []common.Hash
+}
+
+func newHeaderCache() *headerCache {
+	return &headerCache{
+		headers: make(map[common.Hash]*types.Header),
+	}
+}
+
+func (c *headerCache) get(hash common.Hash) *types.Header {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.headers[hash]
+}
+
+func (c *headerCache) add(header *types.Header) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hash := header.Hash()
+	if _, ok := c.headers[hash]; ok {
+		return
+	}
+	if len(c.order) >= headerCacheSize {
+		delete(c.headers, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.headers[hash] = header
+	c.order = append(c.order, hash)
+}
+
+// headerByHash returns the block header from cache or node
+func (e *ETH) headerByHash(hash common.Hash) (*types.Header, error) {
+	if header := e.headers.get(hash); header != nil {
+		return header, nil
+	}
+	var header *types.Header
+	_, err := e.endpoints.call(func(ep *endpoint) (err error) {
+		header, err = ep.ethClient.HeaderByHash(context.Background(), hash)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	e.headers.add(header)
+	return header, nil
+}
+
+// getReceipt queries the receipt of transaction once, returns ethereum.NotFound if it is not mined
+func (e *ETH) getReceipt(hash common.Hash) (*receipt, error) {
+	var raw json.RawMessage
+	_, err := e.endpoints.call(func(ep *endpoint) error {
+		return ep.rpcClient.CallContext(context.Background(), &raw, "eth_getTransactionReceipt", hash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseReceipt(raw)
+}
+
+// parseReceipt parses the receipt in json, returns ethereum.NotFound if it is null
+func parseReceipt(raw json.RawMessage) (*receipt, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, ethereum.NotFound
+	}
+	r := &types.Receipt{}
+	if err := json.Unmarshal(raw, r); err != nil {
+		return nil, err
+	}
+	var extra struct {
+		EffectiveGasPrice *hexutil.Big `json:"effectiveGasPrice"`
+	}
+	if err := json.Unmarshal(raw, &extra); err != nil {
+		return nil, err
+	}
+	ret := &receipt{Receipt: r}
+	if extra.EffectiveGasPrice != nil {
+		ret.EffectiveGasPrice = extra.EffectiveGasPrice.ToInt()
+	}
+	return ret, nil
+}
+
+// waitReceipt polls the receipt of transaction until it is mined or confirm timeout
+func (e *ETH) waitReceipt(hash common.Hash) (*receipt, error) {
+	deadline := time.Now().Add(e.op.confirmTimeout)
+	for {
+		r, err := e.getReceipt(hash)
+		if err == nil {
+			return r, nil
+		}
+		if err != ethereum.NotFound {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, errReceiptTimeout
+		}
+		time.Sleep(e.op.confirmInterval)
+	}
+}
+
+// effectiveGasPrice returns the gas price paid by transaction in the block with given header,
+// it is computed from transaction when node does not return it in receipt
+func (e *ETH) effectiveGasPrice(r *receipt, header *types.Header) (*big.Int, error) {
+	if r.EffectiveGasPrice != nil {
+		return r.EffectiveGasPrice, nil
+	}
+	var tx *types.Transaction
+	_, err := e.endpoints.call(func(ep *endpoint) (err error) {
+		tx, _, err = ep.ethClient.TransactionByHash(context.Background(), r.TxHash)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if header.BaseFee == nil {
+		return tx.GasPrice(), nil
+	}
+	return new(big.Int).Add(header.BaseFee, tx.EffectiveGasTipValue(header.BaseFee)), nil
+}
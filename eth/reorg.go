@@ -0,0 +1,298 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide the confirmation depth of transactions and the detection of reorgs
+ * @file reorg.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// reorgKey is the key of how the transaction is affected by reorg in the result of confirm
+	reorgKey = "reorg"
+	// reorgDropped is the transaction removed from chain by reorg,
+	// reorgReincluded is the transaction included again in another block,
+	// reorgOrphaned is the transaction whose block is reorganized but whose receipt on new chain is not indexed in time
+	reorgDropped    = "dropped"
+	reorgReincluded = "reincluded"
+	reorgOrphaned   = "orphaned"
+	// reorgPollInterval is the interval of polling the head of chain for reorg on master
+	reorgPollInterval = time.Second
+	// maxStaleReceipts is how many times the receipt is polled after its block is reorganized,
+	// before the transaction is reported dropped or orphaned
+	maxStaleReceipts = 10
+	// confirmationsKey is the key of confirmation depth in the result of confirm
+	confirmationsKey = "confirmations"
+)
+
+// errConfirmTimeout is returned when the transaction is not buried deep enough before confirm timeout
+var errConfirmTimeout = errors.New("wait for confirmations timeout")
+
+// orphanedBlock is the transactions of the block removed from chain by reorg
+type orphanedBlock struct {
+	txs []common.Hash
+	// time is when the reorg is seen
+	time int64
+}
+
+// reorgTracker follows the head of chain on master and keeps the transactions of blocks removed by reorg,
+// they are counted by statistic whichever process sent them
+type reorgTracker struct {
+	// canonical are the recent blocks on chain by number, they are only accessed by the polling goroutine
+	canonical map[uint64]*blockStat
+
+	mu       sync.Mutex
+	orphaned []orphanedBlock
+}
+
+func newReorgTracker() *reorgTracker {
+	return &reorgTracker{canonical: make(map[uint64]*blockStat)}
+}
+
+// update walks back from the new head until it meets the recorded chain, the recorded blocks replaced
+// on the way or above the head are orphaned. At most maxCatchUp parents are fetched by one update.
+func (t *reorgTracker) update(head *blockStat, parentOf func(b *blockStat) (*blockStat, error)) error {
+	now := time.Now().UnixNano()
+	var orphaned []*blockStat
+	for n, b := range t.canonical {
+		if n > uint64(head.Number) {
+			orphaned = append(orphaned, b)
+			delete(t.canonical, n)
+		}
+	}
+	b := head
+	for fetched := 0; ; fetched++ {
+		n := uint64(b.Number)
+		old, ok := t.canonical[n]
+		if ok && old.Hash == b.Hash {
+			break
+		}
+		if ok {
+			orphaned = append(orphaned, old)
+		}
+		t.canonical[n] = b
+		parent, ok := t.canonical[n-1]
+		if n == 0 || fetched >= maxCatchUp || (ok && parent.Hash == b.ParentHash) || (!ok && len(t.canonical) == 1) {
+			break
+		}
+		var err error
+		if b, err = parentOf(b); err != nil {
+			return err
+		}
+	}
+	for n := range t.canonical {
+		if n+recentBlocks < uint64(head.Number) {
+			delete(t.canonical, n)
+		}
+	}
+	if len(orphaned) == 0 {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, b := range orphaned {
+		t.orphaned = append(t.orphaned, orphanedBlock{txs: b.Transactions, time: now})
+	}
+	return nil
+}
+
+// take returns the number of orphaned blocks and the transactions orphaned in [from, to], and forgets them
+func (t *reorgTracker) take(from, to int64) (int, []common.Hash) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var (
+		blocks int
+		hashes []common.Hash
+	)
+	kept := t.orphaned[:0]
+	for _, b := range t.orphaned {
+		if b.time >= from && b.time <= to {
+			blocks++
+			hashes = append(hashes, b.txs...)
+		} else {
+			kept = append(kept, b)
+		}
+	}
+	t.orphaned = kept
+	return blocks, hashes
+}
+
+// blockStatOf fetches the block by method, the block not found is ethereum.NotFound
+func (e *ETH) blockStatOf(method string, arg interface{}) (*blockStat, error) {
+	var b *blockStat
+	_, err := e.endpoints.call(func(ep *endpoint) error {
+		return ep.rpcClient.CallContext(context.Background(), &b, method, arg, false)
+	})
+	if err == nil && b == nil {
+		err = ethereum.NotFound
+	}
+	return b, err
+}
+
+// pollReorgs updates the reorg tracker by the head of chain
+func (e *ETH) pollReorgs() error {
+	head, err := e.blockStatOf("eth_getBlockByNumber", "latest")
+	if err != nil {
+		return err
+	}
+	return e.reorgs.update(head, func(b *blockStat) (*blockStat, error) {
+		return e.blockStatOf("eth_getBlockByHash", b.ParentHash)
+	})
+}
+
+// trackReorgs starts the reorg tracker of master, it is started once by the first chain status
+func (e *ETH) trackReorgs() {
+	if e.reorgs != nil {
+		return
+	}
+	e.reorgs = newReorgTracker()
+	if err := e.pollReorgs(); err != nil {
+		e.Logger.Errorf("track reorg failed: %v", err)
+	}
+	go func() {
+		for range time.Tick(reorgPollInterval) {
+			if err := e.pollReorgs(); err != nil {
+				e.Logger.Errorf("track reorg failed: %v", err)
+			}
+		}
+	}()
+}
+
+// parseConfirmations parses the option `confirmations`, a non-negative number of blocks
+func parseConfirmations(value interface{}) (uint64, error) {
+	n, ok := value.(float64)
+	if !ok {
+		return 0, errors.New("option `confirmations` type error: " + reflect.TypeOf(value).Name())
+	}
+	if n < 0 {
+		return 0, errors.New("option `confirmations` value error: negative")
+	}
+	return uint64(n), nil
+}
+
+// awaitConfirmations waits until the block of receipt is buried by `confirmations` blocks,
+// then checks the block is still canonical. The receipt is looked up again if the block is reorganized,
+// the transaction is re-included if it is found in another block, or dropped with nil receipt if not found.
+// The transaction is dropped or orphaned if it is not found or the stale receipt is still returned by node
+// after maxStaleReceipts polls or confirm timeout, the reorg observed is returned with errors.
+func (e *ETH) awaitConfirmations(hash common.Hash, r *receipt) (*receipt, *types.Header, string, error) {
+	deadline := time.Now().Add(e.op.confirmTimeout)
+	reorg, stale := "", 0
+	for {
+		target := r.BlockNumber.Uint64() + e.op.confirmations
+		for {
+			var head uint64
+			_, err := e.endpoints.call(func(ep *endpoint) (err error) {
+				head, err = ep.ethClient.BlockNumber(context.Background())
+				return err
+			})
+			if err != nil {
+				return nil, nil, reorg, err
+			}
+			if head >= target {
+				break
+			}
+			if time.Now().After(deadline) {
+				return nil, nil, reorg, errConfirmTimeout
+			}
+			time.Sleep(e.op.confirmInterval)
+		}
+
+		var header *types.Header
+		_, err := e.endpoints.call(func(ep *endpoint) (err error) {
+			header, err = ep.ethClient.HeaderByNumber(context.Background(), r.BlockNumber)
+			return err
+		})
+		if err != nil {
+			return nil, nil, reorg, err
+		}
+		if header.Hash() == r.BlockHash {
+			return r, header, reorg, nil
+		}
+		// the transaction is usually returned to txpool by the reorg and included again later,
+		// it is dropped if it is not found in maxStaleReceipts polls or before confirm timeout
+		latest, err := e.getReceipt(hash)
+		if err == ethereum.NotFound {
+			if stale++; stale >= maxStaleReceipts || time.Now().After(deadline) {
+				return nil, nil, reorgDropped, nil
+			}
+			time.Sleep(e.op.confirmInterval)
+			continue
+		}
+		if err != nil {
+			return nil, nil, reorg, err
+		}
+		if latest.BlockHash != r.BlockHash {
+			reorg, r, stale = reorgReincluded, latest, 0
+			continue
+		}
+		// the node has not indexed the receipt of new chain yet
+		if stale++; stale >= maxStaleReceipts || time.Now().After(deadline) {
+			return nil, nil, reorgOrphaned, errConfirmTimeout
+		}
+		time.Sleep(e.op.confirmInterval)
+	}
+}
+
+// countReorgs returns the number of blocks orphaned by reorg in [from, to] and their transactions
+// reincluded or dropped, the transaction is reincluded if it is found on chain at the statistic
+func (e *ETH) countReorgs(from, to int64) (blocks, reincluded, dropped int, err error) {
+	if e.reorgs == nil {
+		return 0, 0, 0, nil
+	}
+	blocks, hashes := e.reorgs.take(from, to)
+	var receipts []*types.Receipt
+	_, err = e.endpoints.call(func(ep *endpoint) (err error) {
+		receipts, err = statisticScanner(ep.rpcClient).receipts(hashes)
+		return err
+	})
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	for _, r := range receipts {
+		if r == nil {
+			dropped++
+		}
+	}
+	return blocks, len(hashes) - dropped, dropped, nil
+}
+
+// logReorgs logs the blocks orphaned by reorg in [from, to] and how their transactions are affected
+func (e *ETH) logReorgs(from, to int64) {
+	blocks, reincluded, dropped, err := e.countReorgs(from, to)
+	if err != nil {
+		e.Logger.Errorf("query receipts of orphaned transactions failed: %v", err)
+		return
+	}
+	if blocks == 0 {
+		return
+	}
+	e.Logger.Noticef("%v blocks orphaned by reorg with %v transactions: %v reincluded, %v dropped",
+		blocks, reincluded+dropped, reincluded, dropped)
+}
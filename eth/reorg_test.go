@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	fcom "github.com/hyperbench/hyperbench-common/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// forkChain replaces the blocks of simulated chain after parent by n blocks, the first one includes txs
+func forkChain(t *testing.T, c *simulatedChain, parent common.Hash, n int, txs ...*types.Transaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	chain := c.backend.Blockchain()
+	blocks, _ := core.GenerateChain(chain.Config(), chain.GetBlockByHash(parent), ethash.NewFaker(), c.db, n, func(i int, block *core.BlockGen) {
+		block.OffsetTime(-9)
+		block.SetExtra([]byte("fork"))
+		if i == 0 {
+			for _, tx := range txs {
+				block.AddTxWithChain(chain, tx)
+			}
+		}
+	})
+	_, err := chain.InsertChain(blocks)
+	assert.NoError(t, err)
+	assert.NoError(t, c.backend.Fork(context.Background(), blocks[n-1].Hash()))
+}
+
+func TestParseConfirmations(t *testing.T) {
+	n, err := parseConfirmations(float64(3))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), n)
+	_, err = parseConfirmations(float64(-1))
+	assert.Error(t, err)
+	_, err = parseConfirmations("3")
+	assert.Error(t, err)
+}
+
+func TestConfirmations(t *testing.T) {
+	client, stop := newSimulatedClient(t, storageABI, storageBIN)
+	defer stop()
+	assert.NoError(t, client.DeployContract())
+	assert.NoError(t, client.Option(fcom.Option{"confirmations": float64(1)}))
+
+	// the transaction is confirmed after a block on top of it
+	res := client.Invoke(fcom.Invoke{Func: "set", Args: []interface{}{float64(1)}})
+	other := accountAlias(accounts["other"])
+	client.Transfer(fcom.Transfer{From: "main", To: other, Amount: 1})
+	res = client.Confirm(res)
+	assert.Equal(t, fcom.Confirm, res.Status)
+	assert.Equal(t, uint64(1), res.Ret[0].(map[string]interface{})[confirmationsKey])
+	confirmed := common.HexToHash(res.UID)
+
+	// the transaction included again in the block of another fork
+	res = client.Invoke(fcom.Invoke{Func: "set", Args: []interface{}{float64(2)}})
+	hash := common.HexToHash(res.UID)
+	r, err := client.getReceipt(hash)
+	assert.NoError(t, err)
+	tx, _, err := client.ethClient().TransactionByHash(context.Background(), hash)
+	assert.NoError(t, err)
+	block, err := client.ethClient().HeaderByHash(context.Background(), r.BlockHash)
+	assert.NoError(t, err)
+	forkChain(t, simulated, block.ParentHash, 2, tx)
+	r2, header, reorg, err := client.awaitConfirmations(hash, r)
+	assert.NoError(t, err)
+	assert.Equal(t, reorgReincluded, reorg)
+	assert.NotEqual(t, r.BlockHash, r2.BlockHash)
+	assert.Equal(t, r2.BlockHash, header.Hash())
+
+	// the transaction dropped by reorg is not found after polls
+	client.op.confirmInterval = time.Millisecond
+	res = client.Invoke(fcom.Invoke{Func: "set", Args: []interface{}{float64(3)}})
+	hash = common.HexToHash(res.UID)
+	r, err = client.getReceipt(hash)
+	assert.NoError(t, err)
+	block, err = client.ethClient().HeaderByHash(context.Background(), r.BlockHash)
+	assert.NoError(t, err)
+	forkChain(t, simulated, block.ParentHash, 2)
+	r2, _, reorg, err = client.awaitConfirmations(hash, r)
+	assert.NoError(t, err)
+	assert.Equal(t, reorgDropped, reorg)
+	assert.Nil(t, r2)
+
+	// the transaction not found right after reorg is reincluded by a later block
+	tx, _, err = client.ethClient().TransactionByHash(context.Background(), hash)
+	assert.Error(t, err)
+	tx = simulated.backend.Blockchain().GetBlockByHash(r.BlockHash).Transactions()[0]
+	client.op.confirmInterval = 5 * time.Millisecond
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		forkChain(t, simulated, simulated.backend.Blockchain().CurrentBlock().Hash(), 2, tx)
+	}()
+	r2, _, reorg, err = client.awaitConfirmations(hash, r)
+	assert.NoError(t, err)
+	assert.Equal(t, reorgReincluded, reorg)
+	assert.NotNil(t, r2)
+	client.op.confirmInterval = time.Millisecond
+
+	// the stale receipt of reorganized block is polled a bounded number of times,
+	// it is simulated by the receipt whose block is not at its number
+	r, err = client.getReceipt(confirmed)
+	assert.NoError(t, err)
+	stale := *r.Receipt
+	stale.BlockNumber = new(big.Int).Sub(r.BlockNumber, common.Big1)
+	r, _, reorg, err = client.awaitConfirmations(confirmed, &receipt{Receipt: &stale})
+	assert.Equal(t, errConfirmTimeout, err)
+	assert.Equal(t, reorgOrphaned, reorg)
+	assert.Nil(t, r)
+}
+
+func TestReorgTracker(t *testing.T) {
+	client, stop := newSimulatedClient(t, storageABI, storageBIN)
+	defer stop()
+	assert.NoError(t, client.DeployContract())
+	client.reorgs = newReorgTracker()
+	assert.NoError(t, client.pollReorgs())
+	start := time.Now().UnixNano()
+
+	// the transaction of orphaned block is included again by the fork
+	res := client.Invoke(fcom.Invoke{Func: "set", Args: []interface{}{float64(1)}})
+	hash := common.HexToHash(res.UID)
+	r, err := client.getReceipt(hash)
+	assert.NoError(t, err)
+	assert.NoError(t, client.pollReorgs())
+	tx, _, err := client.ethClient().TransactionByHash(context.Background(), hash)
+	assert.NoError(t, err)
+	block, err := client.ethClient().HeaderByHash(context.Background(), r.BlockHash)
+	assert.NoError(t, err)
+	forkChain(t, simulated, block.ParentHash, 2, tx)
+	assert.NoError(t, client.pollReorgs())
+
+	// the transaction of orphaned block is dropped by the fork
+	res = client.Invoke(fcom.Invoke{Func: "set", Args: []interface{}{float64(2)}})
+	r, err = client.getReceipt(common.HexToHash(res.UID))
+	assert.NoError(t, err)
+	assert.NoError(t, client.pollReorgs())
+	block, err = client.ethClient().HeaderByHash(context.Background(), r.BlockHash)
+	assert.NoError(t, err)
+	forkChain(t, simulated, block.ParentHash, 2)
+	assert.NoError(t, client.pollReorgs())
+
+	blocks, reincluded, dropped, err := client.countReorgs(start, time.Now().UnixNano())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, blocks)
+	assert.Equal(t, 1, reincluded)
+	assert.Equal(t, 1, dropped)
+	blocks, _, _, err = client.countReorgs(start, time.Now().UnixNano())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, blocks)
+}
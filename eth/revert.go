@@ -0,0 +1,369 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide the revert reasons of failed transactions
+ * @file revert.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/spf13/viper"
+)
+
+const (
+	// revertKey is the key of revert reason in result
+	revertKey = "revert"
+	// revertNoReason is the reason of reverting without data
+	revertNoReason = "reverted without reason"
+	// revertNotReplayed is the reason counted for the failed transactions over the replay limit
+	revertNotReplayed = "not replayed"
+	// defaultRevertReplays is the number of failed transactions replayed by a statistic
+	defaultRevertReplays = 100
+)
+
+var (
+	// errorSelector is the selector of Error(string)
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	// panicSelector is the selector of Panic(uint256)
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+	// panicCodes are the descriptions of panic codes of solidity
+	panicCodes = map[uint64]string{
+		0x00: "generic panic",
+		0x01: "assertion failed",
+		0x11: "arithmetic overflow or underflow",
+		0x12: "division or modulo by zero",
+		0x21: "invalid enum value",
+		0x22: "invalid storage byte array",
+		0x31: "pop on empty array",
+		0x32: "array index out of bounds",
+		0x41: "out of memory",
+		0x51: "call to zero-initialized function",
+	}
+)
+
+// revertError is returned by invoking when the precheck call reverts
+type revertError struct {
+	reason string
+}
+
+func (e *revertError) Error() string {
+	return "precheck reverted: " + e.reason
+}
+
+// customError is an error defined in contract abi
+type customError struct {
+	name   string
+	inputs abi.Arguments
+}
+
+// parseCustomErrors parses the custom errors in abi keyed by selector
+func parseCustomErrors(abiJSON string) map[string]*customError {
+	var fields []struct {
+		Type   string
+		Name   string
+		Inputs []abi.ArgumentMarshaling
+	}
+	errs := make(map[string]*customError)
+	if err := json.Unmarshal([]byte(abiJSON), &fields); err != nil {
+		return errs
+	}
+	for _, field := range fields {
+		if field.Type != "error" {
+			continue
+		}
+		c := &customError{name: field.Name}
+		sigs := make([]string, 0, len(field.Inputs))
+		for _, input := range field.Inputs {
+			t, err := abi.NewType(input.Type, input.InternalType, input.Components)
+			if err != nil {
+				break
+			}
+			c.inputs = append(c.inputs, abi.Argument{Name: input.Name, Type: t})
+			sigs = append(sigs, t.String())
+		}
+		if len(c.inputs) != len(field.Inputs) {
+			continue
+		}
+		selector := crypto.Keccak256([]byte(field.Name + "(" + strings.Join(sigs, ",") + ")"))[:4]
+		errs[string(selector)] = c
+	}
+	return errs
+}
+
+// customErrorRegistry keeps the custom errors of all parsed abis,
+// so that a revert is decoded whichever contract raised it
+type customErrorRegistry struct {
+	mu   sync.RWMutex
+	errs map[string]*customError
+}
+
+// customErrors are the custom errors registered by parseABI keyed by selector
+var customErrors = &customErrorRegistry{errs: make(map[string]*customError)}
+
+func (r *customErrorRegistry) register(abiJSON string) {
+	errs := parseCustomErrors(abiJSON)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for selector, c := range errs {
+		r.errs[selector] = c
+	}
+}
+
+func (r *customErrorRegistry) get(selector []byte) (*customError, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.errs[string(selector)]
+	return c, ok
+}
+
+// parseABI parses the contract abi, the custom errors are not supported by abi package,
+// they are stripped from the abi and registered for decoding revert reasons
+func parseABI(abiJSON string) (abi.ABI, error) {
+	var fields []json.RawMessage
+	if err := json.Unmarshal([]byte(abiJSON), &fields); err != nil {
+		return abi.ABI{}, err
+	}
+	kept := fields[:0]
+	for _, field := range fields {
+		var entry struct {
+			Type string
+		}
+		if err := json.Unmarshal(field, &entry); err != nil {
+			return abi.ABI{}, err
+		}
+		if entry.Type != "error" {
+			kept = append(kept, field)
+		}
+	}
+	stripped, err := json.Marshal(kept)
+	if err != nil {
+		return abi.ABI{}, err
+	}
+	parsed, err := abi.JSON(bytes.NewReader(stripped))
+	if err != nil {
+		return abi.ABI{}, err
+	}
+	if len(kept) < len(fields) {
+		customErrors.register(abiJSON)
+	}
+	return parsed, nil
+}
+
+// decodeRevert decodes the revert data as Error(string), Panic(uint256) or a registered custom error
+func decodeRevert(data []byte) string {
+	if len(data) == 0 {
+		return revertNoReason
+	}
+	if len(data) >= 4 {
+		selector := data[:4]
+		switch {
+		case bytes.Equal(selector, errorSelector):
+			if reason, err := abi.UnpackRevert(data); err == nil {
+				return reason
+			}
+		case bytes.Equal(selector, panicSelector):
+			if len(data) == 36 {
+				code := new(big.Int).SetBytes(data[4:])
+				desc, ok := panicCodes[code.Uint64()]
+				if !ok || !code.IsUint64() {
+					desc = "unknown panic"
+				}
+				return fmt.Sprintf("panic 0x%x: %v", code, desc)
+			}
+		default:
+			if c, ok := customErrors.get(selector); ok {
+				values, err := c.inputs.Unpack(data[4:])
+				if err == nil {
+					args := make([]string, len(values))
+					for i, value := range values {
+						args[i] = fmt.Sprintf("%v", plainValue(value))
+					}
+					return c.name + "(" + strings.Join(args, ", ") + ")"
+				}
+			}
+		}
+	}
+	return "unknown error " + hexutil.Encode(data)
+}
+
+// callRevert returns the revert reason of the error of eth_call,
+// the revert data is preferred to the message of error
+func callRevert(err error) string {
+	if dataErr, ok := err.(rpc.DataError); ok {
+		if data, ok := dataErr.ErrorData().(string); ok {
+			if raw, err := hexutil.Decode(data); err == nil {
+				return decodeRevert(raw)
+			}
+		}
+	}
+	if err.Error() == "execution reverted" {
+		return revertNoReason
+	}
+	return err.Error()
+}
+
+// precheck calls the contract on pending state before sending,
+// an error of revertError is returned if the call fails
+func (e *ETH) precheck(from common.Address, to *common.Address, value *big.Int, data []byte) error {
+	_, err := e.endpoints.call(func(ep *endpoint) error {
+		_, err := ep.ethClient.PendingCallContract(context.Background(), ethereum.CallMsg{
+			From:  from,
+			To:    to,
+			Value: value,
+			Data:  data,
+		})
+		return err
+	})
+	if err == nil {
+		return nil
+	}
+	if isEndpointError(err) {
+		return err
+	}
+	return &revertError{reason: callRevert(err)}
+}
+
+// replayRevert replays the failed transaction by eth_call on the state of parent block to find the revert reason.
+// The transactions before it in the same block are not applied, the reason may differ from the original
+// if it depends on them, e.g. the transaction failed by a balance spent earlier in the block.
+func (e *ETH) replayRevert(r *receipt) (string, error) {
+	var tx *types.Transaction
+	_, err := e.endpoints.call(func(ep *endpoint) (err error) {
+		tx, _, err = ep.ethClient.TransactionByHash(context.Background(), r.TxHash)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(e.chainID), tx)
+	if err != nil {
+		return "", err
+	}
+	_, err = e.endpoints.call(func(ep *endpoint) error {
+		_, err := ep.ethClient.CallContract(context.Background(), ethereum.CallMsg{
+			From:  from,
+			To:    tx.To(),
+			Gas:   tx.Gas(),
+			Value: tx.Value(),
+			Data:  tx.Data(),
+		}, new(big.Int).Sub(r.BlockNumber, common.Big1))
+		return err
+	})
+	if err == nil {
+		// the call succeeds without the transactions before it in the block
+		if r.GasUsed == tx.Gas() {
+			return "out of gas", nil
+		}
+		return "unknown, the replay succeeded", nil
+	}
+	if isEndpointError(err) {
+		return "", err
+	}
+	return callRevert(err), nil
+}
+
+// revertCount is the count of a reason
+type revertCount struct {
+	reason string
+	count  int
+}
+
+// countReverts finds the failed transactions in blocks by their receipts and counts them by revert reason,
+// the reasons of the first `statistic.replays` in eth.toml are found by replaying and the rest are counted as not replayed,
+// a negative value replays none.
+// The transactions reverted by precheck are never sent, they are only in the results by revertKey.
+func (e *ETH) countReverts(blocks []*blockStat) ([]revertCount, error) {
+	var hashes []common.Hash
+	for _, b := range blocks {
+		hashes = append(hashes, b.Transactions...)
+	}
+	var receipts []*types.Receipt
+	_, err := e.endpoints.call(func(ep *endpoint) (err error) {
+		receipts, err = statisticScanner(ep.rpcClient).receipts(hashes)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	replays := viper.GetInt("statistic.replays")
+	if replays == 0 {
+		replays = defaultRevertReplays
+	}
+	reasons := make(map[string]int)
+	for _, r := range receipts {
+		if r == nil || r.Status != types.ReceiptStatusFailed {
+			continue
+		}
+		reason := revertNotReplayed
+		if replays > 0 {
+			replays--
+			if reason, err = e.replayRevert(&receipt{Receipt: r}); err != nil {
+				e.Logger.Errorf("replay failed transaction %v failed: %v", r.TxHash.String(), err)
+				reason = revertNotReplayed
+			}
+		}
+		reasons[reason]++
+	}
+	counts := make([]revertCount, 0, len(reasons))
+	for reason, count := range reasons {
+		counts = append(counts, revertCount{reason: reason, count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].count != counts[j].count {
+			return counts[i].count > counts[j].count
+		}
+		return counts[i].reason < counts[j].reason
+	})
+	return counts, nil
+}
+
+// logReverts logs the counts of revert reasons of the failed transactions in blocks
+func (e *ETH) logReverts(blocks []*blockStat) {
+	counts, err := e.countReverts(blocks)
+	if err != nil {
+		e.Logger.Errorf("count failed transactions failed: %v", err)
+		return
+	}
+	if len(counts) == 0 {
+		return
+	}
+	total := 0
+	for _, c := range counts {
+		total += c.count
+	}
+	e.Logger.Noticef("%v transactions failed on chain:", total)
+	for _, c := range counts {
+		e.Logger.Noticef("  %6d  %v", c.count, c.reason)
+	}
+}
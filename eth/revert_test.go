@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	fcom "github.com/hyperbench/hyperbench-common/common"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// panicABI and panicBIN are a contract always reverting with Panic(0x11)
+const (
+	panicABI = `[{"inputs":[],"name":"add","outputs":[],"stateMutability":"nonpayable","type":"function"},
+		{"inputs":[{"name":"available","type":"uint256"},{"name":"owner","type":"address"}],"name":"Insufficient","type":"error"}]`
+	panicBIN = "601580600b6000396000f3" + "634e487b7160e01b600052601160045260246000fd"
+)
+
+func TestDecodeRevert(t *testing.T) {
+	// custom errors are not supported by abi package, they are registered by parsing
+	parsed, err := parseABI(panicABI)
+	assert.NoError(t, err)
+	assert.Contains(t, parsed.Methods, "add")
+	assert.Equal(t, revertNoReason, decodeRevert(nil))
+
+	stringType, _ := abi.NewType("string", "", nil)
+	data, err := abi.Arguments{{Type: stringType}}.Pack("balance is not enough")
+	assert.NoError(t, err)
+	assert.Equal(t, "balance is not enough", decodeRevert(append(errorSelector, data...)))
+
+	assert.Equal(t, "panic 0x12: division or modulo by zero", decodeRevert(append(panicSelector, common.LeftPadBytes([]byte{0x12}, 32)...)))
+	assert.Equal(t, "panic 0x99: unknown panic", decodeRevert(append(panicSelector, common.LeftPadBytes([]byte{0x99}, 32)...)))
+
+	owner := common.HexToAddress("0x74d366e0649a91395bb122c005917644382b9452")
+	data = append(crypto.Keccak256([]byte("Insufficient(uint256,address)"))[:4], common.LeftPadBytes([]byte{7}, 32)...)
+	data = append(data, common.LeftPadBytes(owner.Bytes(), 32)...)
+	assert.Equal(t, "Insufficient(7, "+owner.Hex()+")", decodeRevert(data))
+	data = append(crypto.Keccak256([]byte("Unregistered(uint256)"))[:4], common.LeftPadBytes([]byte{7}, 32)...)
+	assert.True(t, strings.HasPrefix(decodeRevert(data), "unknown error 0x"))
+	assert.Equal(t, "unknown error 0x1234", decodeRevert([]byte{0x12, 0x34}))
+}
+
+func TestRevertReason(t *testing.T) {
+	client, stop := newSimulatedClient(t, panicABI, panicBIN)
+	defer stop()
+	assert.NoError(t, client.DeployContract())
+	reason := "panic 0x11: arithmetic overflow or underflow"
+
+	// the call before sending reverts
+	assert.NoError(t, client.Option(fcom.Option{"precheck": true}))
+	res := client.Invoke(fcom.Invoke{Func: "add"})
+	assert.Equal(t, fcom.Failure, res.Status)
+	assert.Contains(t, res.Ret, map[string]interface{}{revertKey: reason})
+
+	// the failed transaction is replayed
+	assert.NoError(t, client.Option(fcom.Option{"precheck": false}))
+	res = client.Invoke(fcom.Invoke{Func: "add"})
+	assert.Equal(t, fcom.Success, res.Status)
+	res = client.Confirm(res)
+	assert.Equal(t, fcom.Failure, res.Status)
+	assert.Equal(t, reason, res.Ret[0].(map[string]interface{})[revertKey])
+
+	// the failed transactions on chain are counted by statistic, the precheck one is never sent
+	head, err := client.ethClient().BlockNumber(context.Background())
+	assert.NoError(t, err)
+	blocks, err := statisticScanner(client.rpcClient()).scan(0, int64(head)+1)
+	assert.NoError(t, err)
+	counts, err := client.countReverts(blocks)
+	assert.NoError(t, err)
+	assert.Equal(t, []revertCount{{reason: reason, count: 1}}, counts)
+	viper.Set("statistic.replays", -1)
+	defer viper.Set("statistic.replays", 0)
+	counts, err = client.countReverts(blocks)
+	assert.NoError(t, err)
+	assert.Equal(t, []revertCount{{reason: revertNotReplayed, count: 1}}, counts)
+}
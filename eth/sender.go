@@ -0,0 +1,201 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Manage the senders of transactions and the strategies to choose them
+ * @file sender.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/rand"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	fcom "github.com/hyperbench/hyperbench-common/common"
+)
+
+// strategies to choose sender of invoking contract
+const (
+	// senderFixed uses the account set by option `account`, or the main account
+	senderFixed = "fixed"
+	// senderRoundRobin uses the senders of vm in turn
+	senderRoundRobin = "roundrobin"
+	// senderSticky uses the first sender of vm
+	senderSticky = "sticky"
+	// senderRandom uses a random sender of vm
+	senderRandom = "random"
+)
+
+// sender is an account sending transactions
+type sender struct {
+	alias   string
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func newSender(alias string, key *ecdsa.PrivateKey) *sender {
+	return &sender{
+		alias:   alias,
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+	}
+}
+
+// senderPool contains all accounts and the senders owned by vm
+type senderPool struct {
+	byAlias   map[string]*sender
+	byAddress map[common.Address]*sender
+	// own are the senders used by vm, they are exclusive if exclusive is true
+	own       []*sender
+	exclusive bool
+	// others are the accounts exclusively owned by other vms, vm must not send from them
+	others map[common.Address]bool
+	turn   int
+	rand   *rand.Rand
+}
+
+// newSenderPool creates sender pool from accounts keyed by alias.
+// If there are enough accounts, each vm owns disjoint accounts, the vm with index `i` owns
+// the accounts `i`, `i+vmCount`, `i+2*vmCount` and so on in the order of alias.
+// Otherwise all accounts are shared by vms. The main account is never owned by a vm
+// since every vm sends from it under fixed strategy, its nonces stay partitioned among vms.
+func newSenderPool(accounts map[string]*ecdsa.PrivateKey, main common.Address, vmIndex, vmCount uint64) *senderPool {
+	p := &senderPool{
+		byAlias:   make(map[string]*sender, len(accounts)),
+		byAddress: make(map[common.Address]*sender, len(accounts)),
+		others:    make(map[common.Address]bool),
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano() + int64(vmIndex))),
+	}
+	aliases := make([]string, 0, len(accounts))
+	for alias, key := range accounts {
+		if key == nil {
+			continue
+		}
+		aliases = append(aliases, alias)
+		p.add(newSender(alias, key))
+	}
+	// all vms see the same order of accounts
+	sort.Strings(aliases)
+	ownable := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		if p.byAlias[alias].address != main {
+			ownable = append(ownable, alias)
+		}
+	}
+
+	if vmCount == 0 {
+		vmCount = 1
+	}
+	p.exclusive = len(ownable) > 0 && uint64(len(ownable)) >= vmCount
+	if !p.exclusive {
+		for _, alias := range aliases {
+			p.own = append(p.own, p.byAlias[alias])
+		}
+		return p
+	}
+	for i, alias := range ownable {
+		s := p.byAlias[alias]
+		if uint64(i)%vmCount == vmIndex%vmCount {
+			p.own = append(p.own, s)
+		} else {
+			p.others[s.address] = true
+		}
+	}
+	return p
+}
+
+// check returns an error if the sender is exclusively owned by another vm,
+// the nonces allocated to it one by one there would be allocated here again
+func (p *senderPool) check(s *sender) error {
+	if s != nil && p.others[s.address] {
+		return errors.New("account is owned by another vm: " + s.alias)
+	}
+	return nil
+}
+
+// add adds the sender to pool
+func (p *senderPool) add(s *sender) {
+	p.byAlias[s.alias] = s
+	p.byAddress[s.address] = s
+}
+
+// choose returns the sender by strategy
+func (p *senderPool) choose(strategy string) *sender {
+	if len(p.own) == 0 {
+		return nil
+	}
+	switch strategy {
+	case senderRoundRobin:
+		s := p.own[p.turn%len(p.own)]
+		p.turn++
+		return s
+	case senderRandom:
+		return p.own[p.rand.Intn(len(p.own))]
+	default:
+		return p.own[0]
+	}
+}
+
+// ownSenders lets the nonces of senders exclusively owned by vm be allocated one by one
+func (e *ETH) ownSenders(p *senderPool) {
+	if !p.exclusive {
+		return
+	}
+	for _, s := range p.own {
+		e.nonces.own(s.address)
+	}
+}
+
+// invokeSender returns the sender of invoking contract,
+// the account set in invoke options is preferred to the sender chosen by strategy
+func (e *ETH) invokeSender(ops ...fcom.Option) (*sender, error) {
+	account := ""
+	for _, op := range ops {
+		if value, ok := op["account"]; ok {
+			a, ok := value.(string)
+			if !ok {
+				return nil, errors.New("option `account` type error: " + reflect.TypeOf(value).Name())
+			}
+			account = a
+		}
+	}
+	if account == "" && e.op.sender != senderFixed {
+		if s := e.senders.choose(e.op.sender); s != nil {
+			return s, nil
+		}
+	}
+	if account == "" {
+		account = e.op.account
+	}
+	if account == "" {
+		return e.mainSender, nil
+	}
+	s, ok := e.senders.byAlias[account]
+	if !ok {
+		return nil, errors.New("unknown account: " + account)
+	}
+	if err := e.senders.check(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
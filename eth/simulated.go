@@ -0,0 +1,816 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide the in-process simulated chain served by rpc
+ * @file simulated.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/op/go-logging"
+)
+
+const (
+	// backendSimulated is the value of `rpc.backend` to use the simulated chain
+	backendSimulated = "simulated"
+	// simulatedURL is the url of endpoint and websocket of the simulated chain
+	simulatedURL = "simulated"
+	// defaultSimulatedGasLimit is the gas limit of simulated blocks
+	defaultSimulatedGasLimit = 30000000
+	// defaultSimulatedInterval is how often to commit a block by default
+	defaultSimulatedInterval = time.Second
+	// maxSimulatedAhead is how many seconds blocks are allowed to be ahead of the clock,
+	// the chain rejects the blocks 15 seconds ahead
+	maxSimulatedAhead = 10
+)
+
+// defaultSimulatedBalance is the wei funded to each account in genesis
+var defaultSimulatedBalance = new(big.Int).Mul(big.NewInt(1000000), big.NewInt(params.Ether))
+
+var (
+	simulatedMu sync.Mutex
+	// simulated is the chain shared by all vms in process
+	simulated *simulatedChain
+)
+
+// simulatedConfig is the config of simulated chain
+type simulatedConfig struct {
+	// txs is the number of transactions to commit a block, interval is how often to commit a block,
+	// blocks are committed by the default interval if neither is set
+	txs      int
+	interval time.Duration
+	// flush is how long the transactions fewer than txs wait before committed, the default interval if not set
+	flush    time.Duration
+	balance  *big.Int
+	gasLimit uint64
+}
+
+// simulatedChain is go-ethereum's simulated backend producing blocks by config,
+// it is served by an in-process rpc server so that it is used as a node
+type simulatedChain struct {
+	backend *backends.SimulatedBackend
+	db      ethdb.Database
+	server  *rpc.Server
+	config  simulatedConfig
+	chainID *big.Int
+	signer  types.Signer
+	logger  *logging.Logger
+	quit    chan struct{}
+
+	mu sync.Mutex
+	// txs are the transactions in pending block
+	txs        []*types.Transaction
+	pendingGas uint64
+	// pendingSince is when the first transaction of pending block is sent
+	pendingSince time.Time
+}
+
+// getSimulatedChain returns the simulated chain of process, it is created on first call with accounts funded in genesis
+func getSimulatedChain(accounts map[string]*ecdsa.PrivateKey, config simulatedConfig, logger *logging.Logger) (*simulatedChain, error) {
+	simulatedMu.Lock()
+	defer simulatedMu.Unlock()
+	if simulated != nil {
+		return simulated, nil
+	}
+	c, err := newSimulatedChain(accounts, config, logger)
+	if err != nil {
+		return nil, err
+	}
+	simulated = c
+	return c, nil
+}
+
+func newSimulatedChain(accounts map[string]*ecdsa.PrivateKey, config simulatedConfig, logger *logging.Logger) (*simulatedChain, error) {
+	if config.balance == nil {
+		config.balance = defaultSimulatedBalance
+	}
+	if config.gasLimit == 0 {
+		config.gasLimit = defaultSimulatedGasLimit
+	}
+	if config.txs <= 0 && config.interval <= 0 {
+		config.interval = defaultSimulatedInterval
+	}
+	if config.flush <= 0 {
+		config.flush = defaultSimulatedInterval
+	}
+	alloc := make(core.GenesisAlloc, len(accounts))
+	for _, key := range accounts {
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = core.GenesisAccount{Balance: config.balance}
+	}
+	db := rawdb.NewMemoryDatabase()
+	backend := backends.NewSimulatedBackendWithDatabase(db, alloc, config.gasLimit)
+	chainID := backend.Blockchain().Config().ChainID
+	c := &simulatedChain{
+		backend: backend,
+		db:      db,
+		server:  rpc.NewServer(),
+		config:  config,
+		chainID: chainID,
+		signer:  types.LatestSignerForChainID(chainID),
+		logger:  logger,
+		quit:    make(chan struct{}),
+	}
+	// the genesis is at 1970, the first block is sealed now so that the chain time begins with the clock
+	if err := c.seal(); err != nil {
+		backend.Close()
+		return nil, err
+	}
+	if err := c.server.RegisterName("eth", &simulatedService{chain: c}); err != nil {
+		return nil, err
+	}
+	if err := c.server.RegisterName("txpool", &simulatedTxpool{chain: c}); err != nil {
+		return nil, err
+	}
+	if err := c.server.RegisterName("net", &simulatedNet{chain: c}); err != nil {
+		return nil, err
+	}
+	if err := c.server.RegisterName("debug", &simulatedDebug{chain: c}); err != nil {
+		return nil, err
+	}
+	go c.loop()
+	logger.Noticef("simulated chain %v is started with %v accounts", chainID, len(alloc))
+	return c, nil
+}
+
+// dialEndpoint dials the node of url, the simulated chain is dialed in process
+func dialEndpoint(url string) (*rpc.Client, error) {
+	if url != simulatedURL {
+		return rpc.Dial(url)
+	}
+	simulatedMu.Lock()
+	defer simulatedMu.Unlock()
+	if simulated == nil {
+		return nil, errors.New("simulated chain is not started")
+	}
+	return rpc.DialInProc(simulated.server), nil
+}
+
+// loop commits the pending transactions by interval, if blocks are committed by the number of transactions only,
+// the trailing transactions are committed once they have waited for flush
+func (c *simulatedChain) loop() {
+	tick := c.config.interval
+	if tick <= 0 {
+		tick = c.config.flush
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.mu.Lock()
+			if c.config.interval > 0 || time.Since(c.pendingSince) >= c.config.flush {
+				c.commit()
+			}
+			c.mu.Unlock()
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// commit commits the pending transactions as a block, no block is produced without transactions.
+// It is called with c.mu held, the lock is released while waiting for the clock if blocks run too far ahead of it,
+// the pending transactions may be committed by others meanwhile.
+func (c *simulatedChain) commit() {
+	for len(c.txs) > 0 {
+		wait := c.ahead()
+		if wait <= 0 {
+			break
+		}
+		c.mu.Unlock()
+		time.Sleep(wait)
+		c.mu.Lock()
+	}
+	if len(c.txs) == 0 {
+		return
+	}
+	if err := c.seal(); err != nil {
+		c.logger.Errorf("commit block of simulated chain failed: %v", err)
+	}
+	c.txs, c.pendingGas = nil, 0
+}
+
+// nextTime returns the timestamp of next block, it is the time of clock
+// but at least one second after the parent
+func nextTime(parent *types.Block) int64 {
+	at := int64(parent.Time()) + 1
+	if now := time.Now().Unix(); at < now {
+		at = now
+	}
+	return at
+}
+
+// ahead returns how long the next block waits for the clock, so that blocks are not too far ahead of it
+func (c *simulatedChain) ahead() time.Duration {
+	ahead := nextTime(c.backend.Blockchain().CurrentBlock()) - time.Now().Unix() - maxSimulatedAhead
+	if ahead <= 0 {
+		return 0
+	}
+	return time.Duration(ahead) * time.Second
+}
+
+// seal seals the pending transactions as a block at the time of clock. The simulated backend
+// always puts blocks 10 seconds after their parents, which soon makes them future blocks
+// rejected by chain, so the block is generated here and the pending block is moved onto it.
+// The timestamps of blocks increase by at least one second.
+func (c *simulatedChain) seal() (err error) {
+	chain := c.backend.Blockchain()
+	parent := chain.CurrentBlock()
+	at := nextTime(parent)
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("generate block failed: %v", r)
+		}
+	}()
+	c.backend.Rollback()
+	blocks, _ := core.GenerateChain(chain.Config(), parent, ethash.NewFaker(), c.db, 1, func(_ int, block *core.BlockGen) {
+		block.OffsetTime(at - int64(parent.Time()) - 10)
+		for _, tx := range c.txs {
+			block.AddTxWithChain(chain, tx)
+		}
+	})
+	if _, err = chain.InsertChain(blocks); err != nil {
+		return err
+	}
+	return c.backend.Fork(context.Background(), blocks[0].Hash())
+}
+
+// close stops the simulated chain
+func (c *simulatedChain) close() {
+	close(c.quit)
+	c.server.Stop()
+	c.backend.Close()
+}
+
+// send adds the transaction to pending block, the errors of node are returned instead of panics of simulated backend
+func (c *simulatedChain) send(ctx context.Context, tx *types.Transaction) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if tx.Gas() > c.config.gasLimit {
+		return core.ErrGasLimit
+	}
+	// the pending block is committed before checking the transaction, since the lock may be released by committing
+	for len(c.txs) > 0 && c.pendingGas+tx.Gas() > c.config.gasLimit {
+		c.commit()
+	}
+	if _, _, err = c.backend.TransactionByHash(ctx, tx.Hash()); err == nil {
+		return errors.New("already known")
+	}
+	from, err := types.Sender(c.signer, tx)
+	if err != nil {
+		return err
+	}
+	nonce, err := c.backend.PendingNonceAt(ctx, from)
+	if err != nil {
+		return err
+	}
+	if tx.Nonce() < nonce {
+		return fmt.Errorf("%v: address %v, tx: %d state: %d", core.ErrNonceTooLow, from.Hex(), tx.Nonce(), nonce)
+	}
+	if tx.Nonce() > nonce {
+		return fmt.Errorf("%v: address %v, tx: %d state: %d", core.ErrNonceTooHigh, from.Hex(), tx.Nonce(), nonce)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid transaction: %v", r)
+		}
+	}()
+	if err = c.backend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	if len(c.txs) == 0 {
+		c.pendingSince = time.Now()
+	}
+	c.txs = append(c.txs, tx)
+	c.pendingGas += tx.Gas()
+	if c.config.txs > 0 && len(c.txs) >= c.config.txs {
+		c.commit()
+	}
+	return nil
+}
+
+// simulatedCallArgs are the arguments of eth_call and eth_estimateGas
+type simulatedCallArgs struct {
+	From                 common.Address   `json:"from"`
+	To                   *common.Address  `json:"to"`
+	Gas                  hexutil.Uint64   `json:"gas"`
+	GasPrice             *hexutil.Big     `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big     `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big     `json:"maxPriorityFeePerGas"`
+	Value                *hexutil.Big     `json:"value"`
+	Data                 hexutil.Bytes    `json:"data"`
+	Input                hexutil.Bytes    `json:"input"`
+	AccessList           types.AccessList `json:"accessList"`
+}
+
+func (args *simulatedCallArgs) msg() ethereum.CallMsg {
+	msg := ethereum.CallMsg{
+		From:       args.From,
+		To:         args.To,
+		Gas:        uint64(args.Gas),
+		GasPrice:   (*big.Int)(args.GasPrice),
+		GasFeeCap:  (*big.Int)(args.MaxFeePerGas),
+		GasTipCap:  (*big.Int)(args.MaxPriorityFeePerGas),
+		Value:      (*big.Int)(args.Value),
+		Data:       args.Data,
+		AccessList: args.AccessList,
+	}
+	if len(args.Input) > 0 {
+		msg.Data = args.Input
+	}
+	return msg
+}
+
+// simulatedService serves the eth namespace of simulated chain
+type simulatedService struct {
+	chain *simulatedChain
+}
+
+// blockNumber returns the number of block, nil for the latest or pending block
+func blockNumber(number rpc.BlockNumber) *big.Int {
+	if number < 0 {
+		return nil
+	}
+	return big.NewInt(number.Int64())
+}
+
+func (s *simulatedService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(s.chain.chainID)
+}
+
+func (s *simulatedService) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(s.chain.backend.Blockchain().CurrentHeader().Number.Uint64())
+}
+
+// GasPrice returns the base fee of next block plus the suggested tip
+func (s *simulatedService) GasPrice(ctx context.Context) (*hexutil.Big, error) {
+	tip, err := s.chain.backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	head := s.chain.backend.Blockchain().CurrentHeader()
+	if head.BaseFee != nil {
+		tip.Add(tip, misc.CalcBaseFee(s.chain.backend.Blockchain().Config(), head))
+	}
+	return (*hexutil.Big)(tip), nil
+}
+
+func (s *simulatedService) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	tip, err := s.chain.backend.SuggestGasTipCap(ctx)
+	return (*hexutil.Big)(tip), err
+}
+
+func (s *simulatedService) GetBalance(ctx context.Context, account common.Address, number rpc.BlockNumber) (*hexutil.Big, error) {
+	balance, err := s.chain.backend.BalanceAt(ctx, account, blockNumber(number))
+	return (*hexutil.Big)(balance), err
+}
+
+func (s *simulatedService) GetTransactionCount(ctx context.Context, account common.Address, number rpc.BlockNumber) (hexutil.Uint64, error) {
+	var (
+		nonce uint64
+		err   error
+	)
+	if number == rpc.PendingBlockNumber {
+		nonce, err = s.chain.backend.PendingNonceAt(ctx, account)
+	} else {
+		nonce, err = s.chain.backend.NonceAt(ctx, account, blockNumber(number))
+	}
+	return hexutil.Uint64(nonce), err
+}
+
+func (s *simulatedService) GetCode(ctx context.Context, account common.Address, number rpc.BlockNumber) (hexutil.Bytes, error) {
+	if number == rpc.PendingBlockNumber {
+		return s.chain.backend.PendingCodeAt(ctx, account)
+	}
+	return s.chain.backend.CodeAt(ctx, account, blockNumber(number))
+}
+
+func (s *simulatedService) GetStorageAt(ctx context.Context, account common.Address, key common.Hash, number rpc.BlockNumber) (hexutil.Bytes, error) {
+	return s.chain.backend.StorageAt(ctx, account, key, blockNumber(number))
+}
+
+func (s *simulatedService) Call(ctx context.Context, args simulatedCallArgs, number rpc.BlockNumber) (hexutil.Bytes, error) {
+	if number == rpc.PendingBlockNumber {
+		return s.chain.backend.PendingCallContract(ctx, args.msg())
+	}
+	if number >= 0 && uint64(number) < s.chain.backend.Blockchain().CurrentHeader().Number.Uint64() {
+		return s.callAt(args.msg(), uint64(number))
+	}
+	return s.chain.backend.CallContract(ctx, args.msg(), nil)
+}
+
+// simulatedRevertError is the error of reverted call with the revert data as geth returns
+type simulatedRevertError struct {
+	error
+	data string
+}
+
+func (e *simulatedRevertError) ErrorCode() int {
+	return 3
+}
+
+func (e *simulatedRevertError) ErrorData() interface{} {
+	return e.data
+}
+
+// callAt executes the call on the state of a past block, which is not supported by the simulated backend
+func (s *simulatedService) callAt(call ethereum.CallMsg, number uint64) (hexutil.Bytes, error) {
+	chain := s.chain.backend.Blockchain()
+	header := chain.GetHeaderByNumber(number)
+	if header == nil {
+		return nil, ethereum.NotFound
+	}
+	statedb, err := chain.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	gas := call.Gas
+	if gas == 0 {
+		gas = header.GasLimit
+	}
+	value := call.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	msg := types.NewMessage(call.From, call.To, statedb.GetNonce(call.From), value, gas,
+		new(big.Int), new(big.Int), new(big.Int), call.Data, call.AccessList, false)
+	evm := vm.NewEVM(core.NewEVMBlockContext(header, chain, nil), core.NewEVMTxContext(msg), statedb, chain.Config(), vm.Config{NoBaseFee: true})
+	result, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(gas))
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Revert()) > 0 {
+		err := errors.New("execution reverted")
+		if reason, errUnpack := abi.UnpackRevert(result.Revert()); errUnpack == nil {
+			err = fmt.Errorf("execution reverted: %v", reason)
+		}
+		return nil, &simulatedRevertError{error: err, data: hexutil.Encode(result.Revert())}
+	}
+	return result.Return(), result.Err
+}
+
+func (s *simulatedService) EstimateGas(ctx context.Context, args simulatedCallArgs) (hexutil.Uint64, error) {
+	gas, err := s.chain.backend.EstimateGas(ctx, args.msg())
+	return hexutil.Uint64(gas), err
+}
+
+// CreateAccessList creates the access list of transaction on the latest state,
+// the transaction is applied with the access list until the list is stable like geth
+func (s *simulatedService) CreateAccessList(ctx context.Context, args simulatedCallArgs) (map[string]interface{}, error) {
+	chain := s.chain.backend.Blockchain()
+	head := chain.CurrentBlock()
+	db, err := chain.StateAt(head.Root())
+	if err != nil {
+		return nil, err
+	}
+	call := args.msg()
+	if call.Gas == 0 {
+		call.Gas = head.GasLimit()
+	}
+	if call.Value == nil {
+		call.Value = new(big.Int)
+	}
+	nonce := db.GetNonce(call.From)
+	to := crypto.CreateAddress(call.From, nonce)
+	if call.To != nil {
+		to = *call.To
+	}
+	precompiles := vm.ActivePrecompiles(chain.Config().Rules(head.Number()))
+	prev := vm.NewAccessListTracer(call.AccessList, call.From, to, precompiles)
+	for {
+		list := prev.AccessList()
+		msg := types.NewMessage(call.From, call.To, nonce, call.Value, call.Gas, new(big.Int), new(big.Int), new(big.Int), call.Data, list, true)
+		tracer := vm.NewAccessListTracer(list, call.From, to, precompiles)
+		blockCtx := core.NewEVMBlockContext(head.Header(), chain, nil)
+		evm := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), db.Copy(), chain.Config(), vm.Config{Debug: true, Tracer: tracer, NoBaseFee: true})
+		res, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(msg.Gas()))
+		if err != nil {
+			return nil, err
+		}
+		if tracer.Equal(prev) {
+			result := map[string]interface{}{"accessList": list, "gasUsed": hexutil.Uint64(res.UsedGas)}
+			if res.Err != nil {
+				result["error"] = res.Err.Error()
+			}
+			return result, nil
+		}
+		prev = tracer
+	}
+}
+
+func (s *simulatedService) SendRawTransaction(ctx context.Context, input hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+	if err := s.chain.send(ctx, tx); err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+func (s *simulatedService) GetTransactionByHash(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	tx, pending, err := s.chain.backend.TransactionByHash(ctx, hash)
+	if err == ethereum.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return s.chain.marshalTx(tx, nil, 0)
+	}
+	r, err := s.chain.backend.TransactionReceipt(ctx, hash)
+	if err != nil || r == nil {
+		return nil, err
+	}
+	block := s.chain.backend.Blockchain().GetBlockByHash(r.BlockHash)
+	return s.chain.marshalTx(tx, block, r.TransactionIndex)
+}
+
+func (s *simulatedService) GetTransactionReceipt(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	r, err := s.chain.backend.TransactionReceipt(ctx, hash)
+	if err != nil || r == nil {
+		return nil, err
+	}
+	block := s.chain.backend.Blockchain().GetBlockByHash(r.BlockHash)
+	if block == nil {
+		return nil, nil
+	}
+	tx := block.Transactions()[r.TransactionIndex]
+	fields, err := toFields(r)
+	if err != nil {
+		return nil, err
+	}
+	from, err := types.Sender(s.chain.signer, tx)
+	if err != nil {
+		return nil, err
+	}
+	fields["from"], fields["to"] = from, tx.To()
+	gasPrice := tx.GasPrice()
+	if baseFee := block.BaseFee(); baseFee != nil {
+		tip, err := tx.EffectiveGasTip(baseFee)
+		if err != nil {
+			return nil, err
+		}
+		gasPrice = tip.Add(tip, baseFee)
+	}
+	fields["effectiveGasPrice"] = (*hexutil.Big)(gasPrice)
+	return fields, nil
+}
+
+func (s *simulatedService) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, full bool) (map[string]interface{}, error) {
+	var block *types.Block
+	if number < 0 {
+		block = s.chain.backend.Blockchain().CurrentBlock()
+	} else {
+		block = s.chain.backend.Blockchain().GetBlockByNumber(uint64(number))
+	}
+	if block == nil {
+		return nil, nil
+	}
+	return s.chain.marshalBlock(block, full)
+}
+
+func (s *simulatedService) GetBlockByHash(ctx context.Context, hash common.Hash, full bool) (map[string]interface{}, error) {
+	block := s.chain.backend.Blockchain().GetBlockByHash(hash)
+	if block == nil {
+		return nil, nil
+	}
+	return s.chain.marshalBlock(block, full)
+}
+
+// Syncing returns false since the simulated chain is the only node
+func (s *simulatedService) Syncing() bool {
+	return false
+}
+
+// NewHeads notifies the headers of new blocks
+func (s *simulatedService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	subscription := notifier.CreateSubscription()
+	heads := make(chan *types.Header)
+	sub, err := s.chain.backend.SubscribeNewHead(context.Background(), heads)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case head := <-heads:
+				notifier.Notify(subscription.ID, head)
+			case <-sub.Err():
+				return
+			case <-subscription.Err():
+				return
+			}
+		}
+	}()
+	return subscription, nil
+}
+
+// simulatedTxpool serves the txpool namespace of simulated chain
+type simulatedTxpool struct {
+	chain *simulatedChain
+}
+
+// Status returns the transactions in pending block as pending ones, no transaction is queued
+func (s *simulatedTxpool) Status() map[string]hexutil.Uint {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+	return map[string]hexutil.Uint{
+		"pending": hexutil.Uint(len(s.chain.txs)),
+		"queued":  0,
+	}
+}
+
+// simulatedNet serves the net namespace of simulated chain
+type simulatedNet struct {
+	chain *simulatedChain
+}
+
+func (s *simulatedNet) Version() string {
+	return s.chain.chainID.String()
+}
+
+// PeerCount returns 0 since the simulated chain has no peer
+func (s *simulatedNet) PeerCount() hexutil.Uint {
+	return 0
+}
+
+func (s *simulatedNet) Listening() bool {
+	return false
+}
+
+// simulatedDebug serves the debug namespace of simulated chain
+type simulatedDebug struct {
+	chain *simulatedChain
+}
+
+// simulatedTraceConfig is the config of debug_traceTransaction, only the struct logger is supported
+type simulatedTraceConfig struct {
+	Tracer *string `json:"tracer"`
+}
+
+// TraceTransaction replays the transaction on the state of its block with the struct logger,
+// the result is in the format of geth without stack, memory and storage
+func (s *simulatedDebug) TraceTransaction(ctx context.Context, hash common.Hash, config *simulatedTraceConfig) (map[string]interface{}, error) {
+	if config != nil && config.Tracer != nil {
+		return nil, fmt.Errorf("tracer %v is not supported by simulated chain", *config.Tracer)
+	}
+	chain := s.chain.backend.Blockchain()
+	r, err := s.chain.backend.TransactionReceipt(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	block := chain.GetBlockByHash(r.BlockHash)
+	if block == nil {
+		return nil, ethereum.NotFound
+	}
+	parent := chain.GetBlockByHash(block.ParentHash())
+	if parent == nil {
+		return nil, ethereum.NotFound
+	}
+	statedb, err := chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	blockCtx := core.NewEVMBlockContext(block.Header(), chain, nil)
+	logger := vm.NewStructLogger(&vm.LogConfig{DisableStack: true, DisableStorage: true})
+	for i, tx := range block.Transactions() {
+		msg, err := tx.AsMessage(s.chain.signer, block.BaseFee())
+		if err != nil {
+			return nil, err
+		}
+		vmConfig := vm.Config{}
+		if i == int(r.TransactionIndex) {
+			vmConfig = vm.Config{Debug: true, Tracer: logger}
+		}
+		statedb.Prepare(tx.Hash(), i)
+		evm := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), statedb, chain.Config(), vmConfig)
+		result, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(msg.Gas()))
+		if err != nil {
+			return nil, err
+		}
+		if i < int(r.TransactionIndex) {
+			statedb.Finalise(evm.ChainConfig().IsEIP158(block.Number()))
+			continue
+		}
+		logs := make([]map[string]interface{}, len(logger.StructLogs()))
+		for j, l := range logger.StructLogs() {
+			logs[j] = map[string]interface{}{
+				"pc":      l.Pc,
+				"op":      l.Op.String(),
+				"gas":     l.Gas,
+				"gasCost": l.GasCost,
+				"depth":   l.Depth,
+			}
+		}
+		return map[string]interface{}{
+			"gas":         result.UsedGas,
+			"failed":      result.Failed(),
+			"returnValue": fmt.Sprintf("%x", result.Return()),
+			"structLogs":  logs,
+		}, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+// marshalBlock returns the json fields of block, transactions are hashes unless full is true
+func (c *simulatedChain) marshalBlock(block *types.Block, full bool) (map[string]interface{}, error) {
+	fields, err := toFields(block.Header())
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]interface{}, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		if !full {
+			txs[i] = tx.Hash()
+			continue
+		}
+		if txs[i], err = c.marshalTx(tx, block, uint(i)); err != nil {
+			return nil, err
+		}
+	}
+	fields["transactions"] = txs
+	fields["size"] = hexutil.Uint64(block.Size())
+	fields["uncles"] = []common.Hash{}
+	return fields, nil
+}
+
+// marshalTx returns the json fields of transaction, block is nil if it is pending
+func (c *simulatedChain) marshalTx(tx *types.Transaction, block *types.Block, index uint) (map[string]interface{}, error) {
+	fields, err := toFields(tx)
+	if err != nil {
+		return nil, err
+	}
+	from, err := types.Sender(c.signer, tx)
+	if err != nil {
+		return nil, err
+	}
+	fields["from"] = from
+	if block != nil {
+		fields["blockHash"] = block.Hash()
+		fields["blockNumber"] = (*hexutil.Big)(block.Number())
+		fields["transactionIndex"] = hexutil.Uint64(index)
+	}
+	return fields, nil
+}
+
+// toFields returns the json fields of value
+func toFields(value interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	if err = json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
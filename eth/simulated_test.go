@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/hyperbench/hyperbench-common/base"
+	fcom "github.com/hyperbench/hyperbench-common/common"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// storageABI and storageBIN are a contract storing the argument of `set` in slot 0
+const (
+	storageABI = `[{"inputs":[{"name":"x","type":"uint256"}],"name":"set","outputs":[],"stateMutability":"nonpayable","type":"function"}]`
+	storageBIN = "600780600b6000396000f3" + "60043560005500"
+)
+
+// newSimulatedClient creates the client of simulated chain with the main account and another one funded in genesis,
+// the contract of abi and bin is used
+func newSimulatedClient(t *testing.T, abi, bin string) (*ETH, func()) {
+	dir, err := ioutil.TempDir("", "simulated")
+	assert.NoError(t, err)
+	writeArtifact(t, dir, "eth/eth.toml", "")
+	writeArtifact(t, dir, "contract/contract.abi", abi)
+	writeArtifact(t, dir, "contract/contract.bin", bin)
+
+	main, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	accounts = map[string]*ecdsa.PrivateKey{"main": main, "other": other}
+	PrivateK, PublicK = main, &main.PublicKey
+	fromAddress = crypto.PubkeyToAddress(main.PublicKey)
+	viper.SetConfigType("toml")
+	viper.Set(fcom.EngineCapPath, 1)
+	viper.Set("rpc.backend", backendSimulated)
+	viper.Set("simulated.txs", 1)
+
+	c, err := New(base.NewBlockchainBase(base.ClientConfig{
+		ClientType:   "eth",
+		ConfigPath:   filepath.Join(dir, "eth"),
+		ContractPath: filepath.Join(dir, "contract"),
+	}))
+	assert.NoError(t, err)
+	return c.(*ETH), func() {
+		simulatedMu.Lock()
+		simulated.close()
+		simulated = nil
+		simulatedMu.Unlock()
+		watchersMu.Lock()
+		delete(watchers, simulatedURL)
+		watchersMu.Unlock()
+		viper.Set("rpc.backend", "")
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSimulated(t *testing.T) {
+	client, stop := newSimulatedClient(t, storageABI, storageBIN)
+	defer stop()
+	assert.Equal(t, int64(1337), client.chainID.Int64())
+	assert.True(t, client.london)
+	assert.NotNil(t, client.watcher)
+
+	start, err := client.LogStatus()
+	assert.NoError(t, err)
+	status := client.Query(fcom.Query{Func: BuiltinStatusLabel}).(map[string]interface{})
+	assert.Equal(t, false, status["syncing"])
+	assert.Equal(t, uint64(0), status["pending"])
+	assert.NoError(t, client.DeployContract())
+	code, err := client.ethClient().CodeAt(context.Background(), client.contract.Address, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, common.FromHex("60043560005500"), code)
+
+	res := client.Invoke(fcom.Invoke{Func: "set", Args: []interface{}{float64(42)}})
+	assert.Equal(t, fcom.Success, res.Status)
+	res = client.Confirm(res)
+	assert.Equal(t, fcom.Confirm, res.Status)
+	assert.NotZero(t, res.WriteTime)
+	value, err := client.ethClient().StorageAt(context.Background(), client.contract.Address, [32]byte{}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(42), value[31])
+
+	other := accountAlias(accounts["other"])
+	res = client.Transfer(fcom.Transfer{From: "main", To: other, Amount: 1})
+	assert.Equal(t, fcom.Success, res.Status)
+	assert.Equal(t, fcom.Confirm, client.Confirm(res).Status)
+
+	// the nonce sent is rejected as a node does
+	client.nonces.accounts[fromAddress].round--
+	res = client.Transfer(fcom.Transfer{From: "main", To: other, Amount: 1})
+	assert.Equal(t, fcom.Failure, res.Status)
+
+	end, err := client.LogStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, start.BlockHeight+3, end.BlockHeight)
+	statistic, err := client.Statistic(fcom.Statistic{From: start, To: end})
+	assert.NoError(t, err)
+	// blocks in [start, end) are counted
+	assert.Equal(t, 3, statistic.BlockNum)
+	assert.Equal(t, 2, statistic.TxNum)
+}
+
+func TestSimulatedInterval(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	chain, err := newSimulatedChain(map[string]*ecdsa.PrivateKey{"main": key}, simulatedConfig{interval: 20 * time.Millisecond}, fcom.GetLogger("eth"))
+	assert.NoError(t, err)
+	defer chain.close()
+	service := &simulatedService{chain: chain}
+	assert.Equal(t, hexutil.Uint64(1), service.BlockNumber())
+
+	to := common.HexToAddress("0x74d366e0649a91395bb122c005917644382b9452")
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		tx, err := types.SignNewTx(key, chain.signer, &types.LegacyTx{Nonce: nonce, GasPrice: big.NewInt(params.GWei), Gas: params.TxGas, To: &to, Value: big.NewInt(1)})
+		assert.NoError(t, err)
+		assert.NoError(t, chain.send(context.Background(), tx))
+	}
+	// both transactions are committed in one block by interval
+	assert.Eventually(t, func() bool { return service.BlockNumber() == 2 }, time.Second, 10*time.Millisecond)
+	block, err := service.GetBlockByNumber(context.Background(), rpc.LatestBlockNumber, false)
+	assert.NoError(t, err)
+	assert.Len(t, block["transactions"], 2)
+
+	// nonce errors are returned as node does
+	tx, err := types.SignNewTx(key, chain.signer, &types.LegacyTx{Nonce: 0, GasPrice: big.NewInt(params.GWei), Gas: params.TxGas, To: &to})
+	assert.NoError(t, err)
+	assert.Equal(t, nonceUsed, classifyNonceError(chain.send(context.Background(), tx)))
+	tx, err = types.SignNewTx(key, chain.signer, &types.LegacyTx{Nonce: 5, GasPrice: big.NewInt(params.GWei), Gas: params.TxGas, To: &to})
+	assert.NoError(t, err)
+	assert.Equal(t, nonceFuture, classifyNonceError(chain.send(context.Background(), tx)))
+}
+
+func TestSimulatedFlush(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	chain, err := newSimulatedChain(map[string]*ecdsa.PrivateKey{"main": key}, simulatedConfig{txs: 10, flush: 20 * time.Millisecond}, fcom.GetLogger("eth"))
+	assert.NoError(t, err)
+	defer chain.close()
+	service := &simulatedService{chain: chain}
+
+	// the transactions fewer than txs are committed after flush
+	to := common.HexToAddress("0x74d366e0649a91395bb122c005917644382b9452")
+	tx, err := types.SignNewTx(key, chain.signer, &types.LegacyTx{Nonce: 0, GasPrice: big.NewInt(params.GWei), Gas: params.TxGas, To: &to, Value: big.NewInt(1)})
+	assert.NoError(t, err)
+	assert.NoError(t, chain.send(context.Background(), tx))
+	assert.Eventually(t, func() bool { return service.BlockNumber() == 2 }, time.Second, 10*time.Millisecond)
+}
+
+func TestDeployAddress(t *testing.T) {
+	client, stop := newSimulatedClient(t, storageABI, storageBIN)
+	defer stop()
+	viper.Set("contract.save", true)
+	defer viper.Set("contract.save", false)
+	dir := client.BlockchainBase.ContractPath
+
+	// the address is saved after the contract is deployed
+	assert.NoError(t, client.DeployContract())
+	address := client.contract.Address
+	file, err := addressFile(dir, "contract")
+	assert.NoError(t, err)
+	saved, ok, err := readAddress(file)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, address, saved)
+
+	// the saved contract is used without deploying, workers get its address by context
+	nonce, err := client.ethClient().PendingNonceAt(context.Background(), fromAddress)
+	assert.NoError(t, err)
+	assert.NoError(t, client.DeployContract())
+	assert.Equal(t, address, client.contract.Address)
+	next, err := client.ethClient().PendingNonceAt(context.Background(), fromAddress)
+	assert.NoError(t, err)
+	assert.Equal(t, nonce, next)
+	ctx, err := client.GetContext()
+	assert.NoError(t, err)
+	client.contract = nil
+	assert.NoError(t, client.SetContext(ctx))
+	assert.Equal(t, address, client.contract.Address)
+	res := client.Invoke(fcom.Invoke{Func: "set", Args: []interface{}{float64(1)}})
+	assert.Equal(t, fcom.Confirm, client.Confirm(res).Status)
+
+	// the address without code is refused
+	assert.NoError(t, writeAddress(dir, "contract", common.HexToAddress("0x74d366e0649a91395bb122c005917644382b9452")))
+	assert.Error(t, client.DeployContract())
+
+	// the failed deployment is an error
+	assert.NoError(t, os.Remove(filepath.Join(dir, "contract.addr")))
+	writeArtifact(t, dir, "contract.bin", "60006000fd")
+	assert.Error(t, client.DeployContract())
+	file, err = addressFile(dir, "contract")
+	assert.NoError(t, err)
+	assert.Equal(t, "", file)
+}
+
+func TestSimulatedAhead(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	chain, err := newSimulatedChain(map[string]*ecdsa.PrivateKey{"main": key}, simulatedConfig{txs: 1}, fcom.GetLogger("eth"))
+	assert.NoError(t, err)
+	defer chain.close()
+
+	// blocks of one transaction run ahead of the clock, the sealing waits without holding the lock
+	to := common.HexToAddress("0x74d366e0649a91395bb122c005917644382b9452")
+	done := make(chan error)
+	go func() {
+		for i := uint64(0); i < maxSimulatedAhead+2; i++ {
+			tx, err := types.SignNewTx(key, chain.signer, &types.LegacyTx{Nonce: i, GasPrice: big.NewInt(params.GWei), Gas: params.TxGas, To: &to, Value: big.NewInt(1)})
+			if err == nil {
+				err = chain.send(context.Background(), tx)
+			}
+			if err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	assert.Eventually(t, func() bool { return chain.ahead() > 0 }, 5*time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Eventually(t, func() bool {
+		if !chain.mu.TryLock() {
+			return false
+		}
+		chain.mu.Unlock()
+		return true
+	}, 500*time.Millisecond, time.Millisecond)
+	assert.NoError(t, <-done)
+}
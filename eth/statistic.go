@@ -0,0 +1,261 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide the parallel scan of blocks and the statistic of chain
+ * @file statistic.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/spf13/viper"
+)
+
+const (
+	// defaultScanWorkers is the number of concurrent workers scanning blocks
+	defaultScanWorkers = 8
+	// defaultScanBatch is the number of blocks fetched by one batch request
+	defaultScanBatch = 100
+)
+
+// blockStat is the header fields and transaction count of block
+type blockStat struct {
+	Hash       common.Hash    `json:"hash"`
+	ParentHash common.Hash    `json:"parentHash"`
+	Number     hexutil.Uint64 `json:"number"`
+	Time       hexutil.Uint64 `json:"timestamp"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	GasLimit   hexutil.Uint64 `json:"gasLimit"`
+	BaseFee    *hexutil.Big   `json:"baseFeePerGas"`
+	// Transactions are the hashes of transactions, bodies are not fetched
+	Transactions []common.Hash `json:"transactions"`
+}
+
+// chainStatistic is the statistic of blocks in range
+type chainStatistic struct {
+	blocks int
+	txs    int
+	gas    uint64
+	// span is the chain time of blocks in seconds
+	span uint64
+	// fullness is the average ratio of gasUsed to gasLimit
+	fullness float64
+	// first, last, min and max base fee, nil before london
+	baseFees [4]*big.Int
+}
+
+// tps returns the tps computed by chain timestamps
+func (s *chainStatistic) tps() float64 {
+	if s.span == 0 {
+		return 0
+	}
+	return float64(s.txs) / float64(s.span)
+}
+
+// gasPerSecond returns the gas used per second computed by chain timestamps
+func (s *chainStatistic) gasPerSecond() float64 {
+	if s.span == 0 {
+		return 0
+	}
+	return float64(s.gas) / float64(s.span)
+}
+
+// blockScanner scans blocks by concurrent batch requests
+type blockScanner struct {
+	client  *rpc.Client
+	workers int
+	batch   int
+}
+
+func newBlockScanner(client *rpc.Client, workers, batch int) *blockScanner {
+	if workers <= 0 {
+		workers = defaultScanWorkers
+	}
+	if batch <= 0 {
+		batch = defaultScanBatch
+	}
+	return &blockScanner{
+		client:  client,
+		workers: workers,
+		batch:   batch,
+	}
+}
+
+// statisticScanner returns the block scanner configured by `statistic.workers` and `statistic.batch` in eth.toml
+func statisticScanner(client *rpc.Client) *blockScanner {
+	return newBlockScanner(client, viper.GetInt("statistic.workers"), viper.GetInt("statistic.batch"))
+}
+
+// parallel calls fetch for the batches of [0, n) by concurrent workers, the first error is returned
+func (s *blockScanner) parallel(n int, fetch func(start, end int) error) error {
+	starts := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range starts {
+				end := start + s.batch
+				if end > n {
+					end = n
+				}
+				if err := fetch(start, end); err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+					})
+				}
+			}
+		}()
+	}
+	for start := 0; start < n; start += s.batch {
+		starts <- start
+	}
+	close(starts)
+	wg.Wait()
+	return firstErr
+}
+
+// scan returns the blocks in [from, to) in order
+func (s *blockScanner) scan(from, to int64) ([]*blockStat, error) {
+	if to <= from {
+		return nil, nil
+	}
+	blocks := make([]*blockStat, to-from)
+	err := s.parallel(len(blocks), func(start, end int) error {
+		return s.fetch(from+int64(start), blocks[start:end])
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// receipts returns the receipts of transactions in order, the receipt is nil if the transaction is not found
+func (s *blockScanner) receipts(hashes []common.Hash) ([]*types.Receipt, error) {
+	receipts := make([]*types.Receipt, len(hashes))
+	err := s.parallel(len(hashes), func(start, end int) error {
+		batch := make([]rpc.BatchElem, end-start)
+		for i := range batch {
+			batch[i] = rpc.BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Args:   []interface{}{hashes[start+i]},
+				Result: &receipts[start+i],
+			}
+		}
+		if err := s.client.BatchCallContext(context.Background(), batch); err != nil {
+			return err
+		}
+		for i, elem := range batch {
+			if elem.Error != nil {
+				return fmt.Errorf("query receipt %v failed: %v", hashes[start+i].Hex(), elem.Error)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+// fetch fetches the blocks beginning with start by one batch request
+func (s *blockScanner) fetch(start int64, blocks []*blockStat) error {
+	batch := make([]rpc.BatchElem, len(blocks))
+	for i := range blocks {
+		blocks[i] = &blockStat{}
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.EncodeBig(big.NewInt(start + int64(i))), false},
+			Result: blocks[i],
+		}
+	}
+	if err := s.client.BatchCallContext(context.Background(), batch); err != nil {
+		return err
+	}
+	for i, elem := range batch {
+		if elem.Error != nil {
+			return fmt.Errorf("query block %v failed: %v", start+int64(i), elem.Error)
+		}
+		if uint64(blocks[i].Number) != uint64(start+int64(i)) {
+			return fmt.Errorf("query block %v failed: %v", start+int64(i), ethereum.NotFound)
+		}
+	}
+	return nil
+}
+
+// summarize computes the statistic of blocks, the chain time begins with the parent of first block,
+// or the first block if parent is nil
+func summarize(parent *blockStat, blocks []*blockStat) *chainStatistic {
+	s := &chainStatistic{}
+	if len(blocks) == 0 {
+		return s
+	}
+	if parent == nil {
+		parent = blocks[0]
+	}
+	s.span = uint64(blocks[len(blocks)-1].Time) - uint64(parent.Time)
+	var fullness float64
+	for _, b := range blocks {
+		s.blocks++
+		s.txs += len(b.Transactions)
+		s.gas += uint64(b.GasUsed)
+		if b.GasLimit > 0 {
+			fullness += float64(b.GasUsed) / float64(b.GasLimit)
+		}
+		if b.BaseFee == nil {
+			continue
+		}
+		fee := b.BaseFee.ToInt()
+		if s.baseFees[0] == nil {
+			s.baseFees[0], s.baseFees[2], s.baseFees[3] = fee, fee, fee
+		}
+		s.baseFees[1] = fee
+		if fee.Cmp(s.baseFees[2]) < 0 {
+			s.baseFees[2] = fee
+		}
+		if fee.Cmp(s.baseFees[3]) > 0 {
+			s.baseFees[3] = fee
+		}
+	}
+	s.fullness = fullness / float64(s.blocks)
+	return s
+}
+
+// logChainStatistic logs the statistic not included in remote statistic
+func (e *ETH) logChainStatistic(s *chainStatistic, duration time.Duration) {
+	e.Logger.Noticef("chain tps: %.2f (by chain time %vs), client tps: %.2f (by client time %v)",
+		s.tps(), s.span, float64(s.txs)/duration.Seconds(), duration)
+	e.Logger.Noticef("gas used per second: %.2f, average block fullness: %.2f%%", s.gasPerSecond(), s.fullness*100)
+	if s.baseFees[0] != nil {
+		e.Logger.Noticef("base fee: first %v, last %v, min %v, max %v", s.baseFees[0], s.baseFees[1], s.baseFees[2], s.baseFees[3])
+	}
+}
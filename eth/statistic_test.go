@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeChainService serves blocks with `number` transactions, one second per block
+type fakeChainService struct{}
+
+func (s *fakeChainService) GetBlockByNumber(number hexutil.Uint64, full bool) map[string]interface{} {
+	txs := make([]common.Hash, number)
+	for i := range txs {
+		txs[i] = common.BigToHash(big.NewInt(int64(i)))
+	}
+	return map[string]interface{}{
+		"number":        number,
+		"timestamp":     number,
+		"gasUsed":       hexutil.Uint64(21000 * number),
+		"gasLimit":      hexutil.Uint64(21000 * 100),
+		"baseFeePerGas": (*hexutil.Big)(big.NewInt(int64(1000 - number))),
+		"transactions":  txs,
+	}
+}
+
+func TestBlockScanner(t *testing.T) {
+	server := rpc.NewServer()
+	assert.NoError(t, server.RegisterName("eth", &fakeChainService{}))
+	scanner := newBlockScanner(rpc.DialInProc(server), 3, 7)
+
+	blocks, err := scanner.scan(10, 60)
+	assert.NoError(t, err)
+	assert.Len(t, blocks, 50)
+	for i, b := range blocks {
+		assert.Equal(t, uint64(10+i), uint64(b.Number))
+	}
+
+	s := summarize(blocks[0], blocks[1:])
+	assert.Equal(t, 49, s.blocks)
+	// 11 + 12 + ... + 59
+	assert.Equal(t, 1715, s.txs)
+	assert.Equal(t, uint64(49), s.span)
+	assert.Equal(t, float64(35), s.tps())
+	assert.Equal(t, float64(35*21000), s.gasPerSecond())
+	assert.InDelta(t, 0.35, s.fullness, 1e-9)
+	assert.Equal(t, int64(989), s.baseFees[0].Int64())
+	assert.Equal(t, int64(941), s.baseFees[1].Int64())
+	assert.Equal(t, int64(941), s.baseFees[2].Int64())
+	assert.Equal(t, int64(989), s.baseFees[3].Int64())
+
+	blocks, err = scanner.scan(5, 5)
+	assert.NoError(t, err)
+	assert.Empty(t, blocks)
+}
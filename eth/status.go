@@ -0,0 +1,234 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide the health of node recorded with chain status and the pre-flight check
+ * @file status.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// txpoolStatus is the result of txpool_status
+type txpoolStatus struct {
+	Pending hexutil.Uint64 `json:"pending"`
+	Queued  hexutil.Uint64 `json:"queued"`
+}
+
+// syncProgress is the result of eth_syncing when the node is syncing
+type syncProgress struct {
+	CurrentBlock hexutil.Uint64 `json:"currentBlock"`
+	HighestBlock hexutil.Uint64 `json:"highestBlock"`
+}
+
+// nodeStatus is the health of node, the fields are nil if the node does not support the method
+type nodeStatus struct {
+	txpool *txpoolStatus
+	// syncing is whether the node is syncing, progress is nil if it is not
+	syncing  *bool
+	progress *syncProgress
+	peers    *hexutil.Uint64
+	gasPrice *big.Int
+}
+
+// parseSyncing parses the result of eth_syncing, which is false or the progress of syncing
+func parseSyncing(raw json.RawMessage) (bool, *syncProgress, error) {
+	var syncing bool
+	if err := json.Unmarshal(raw, &syncing); err == nil {
+		return syncing, nil, nil
+	}
+	progress := &syncProgress{}
+	if err := json.Unmarshal(raw, progress); err != nil {
+		return false, nil, err
+	}
+	return true, progress, nil
+}
+
+// queryNodeStatus queries the status of node by one batch request,
+// the methods not supported by node are left unknown
+func queryNodeStatus(client *rpc.Client) (*nodeStatus, error) {
+	var (
+		txpool   txpoolStatus
+		syncing  json.RawMessage
+		peers    hexutil.Uint64
+		gasPrice hexutil.Big
+	)
+	batch := []rpc.BatchElem{
+		{Method: "txpool_status", Result: &txpool},
+		{Method: "eth_syncing", Result: &syncing},
+		{Method: "net_peerCount", Result: &peers},
+		{Method: "eth_gasPrice", Result: &gasPrice},
+	}
+	if err := client.BatchCallContext(context.Background(), batch); err != nil {
+		return nil, err
+	}
+	status := &nodeStatus{}
+	if batch[0].Error == nil {
+		status.txpool = &txpool
+	}
+	if batch[1].Error == nil {
+		if s, progress, err := parseSyncing(syncing); err == nil {
+			status.syncing, status.progress = &s, progress
+		}
+	}
+	if batch[2].Error == nil {
+		status.peers = &peers
+	}
+	if batch[3].Error == nil {
+		status.gasPrice = gasPrice.ToInt()
+	}
+	return status, nil
+}
+
+// info returns the known fields of status as plain values
+func (s *nodeStatus) info() map[string]interface{} {
+	info := make(map[string]interface{})
+	if s.txpool != nil {
+		info["pending"], info["queued"] = uint64(s.txpool.Pending), uint64(s.txpool.Queued)
+	}
+	if s.syncing != nil {
+		info["syncing"] = *s.syncing
+	}
+	if s.progress != nil {
+		info["currentBlock"], info["highestBlock"] = uint64(s.progress.CurrentBlock), uint64(s.progress.HighestBlock)
+	}
+	if s.peers != nil {
+		info["peers"] = uint64(*s.peers)
+	}
+	if s.gasPrice != nil {
+		info["gasPrice"] = s.gasPrice.String()
+	}
+	return info
+}
+
+func (s *nodeStatus) String() string {
+	fields := make([]string, 0, 4)
+	if s.txpool != nil {
+		fields = append(fields, fmt.Sprintf("txpool pending %v queued %v", uint64(s.txpool.Pending), uint64(s.txpool.Queued)))
+	} else {
+		fields = append(fields, "txpool unknown")
+	}
+	switch {
+	case s.progress != nil:
+		fields = append(fields, fmt.Sprintf("syncing %v/%v", uint64(s.progress.CurrentBlock), uint64(s.progress.HighestBlock)))
+	case s.syncing != nil:
+		fields = append(fields, fmt.Sprintf("syncing %v", *s.syncing))
+	default:
+		fields = append(fields, "syncing unknown")
+	}
+	if s.peers != nil {
+		fields = append(fields, fmt.Sprintf("peers %v", uint64(*s.peers)))
+	} else {
+		fields = append(fields, "peers unknown")
+	}
+	if s.gasPrice != nil {
+		fields = append(fields, fmt.Sprintf("gas price %v", s.gasPrice))
+	} else {
+		fields = append(fields, "gas price unknown")
+	}
+	return strings.Join(fields, ", ")
+}
+
+// checkNode refuses the node which is syncing or whose chain id is not the expected one,
+// expected is ignored if it is zero
+func checkNode(client *rpc.Client, chainID *big.Int, expected int64) error {
+	if expected != 0 && chainID.Cmp(big.NewInt(expected)) != 0 {
+		return fmt.Errorf("chain id of node is %v, but %v is expected", chainID, expected)
+	}
+	var raw json.RawMessage
+	if err := client.CallContext(context.Background(), &raw, "eth_syncing"); err != nil {
+		// the node not supporting eth_syncing is not refused
+		if _, ok := err.(rpc.Error); ok {
+			return nil
+		}
+		return err
+	}
+	syncing, progress, err := parseSyncing(raw)
+	if err != nil {
+		return err
+	}
+	if progress != nil {
+		return fmt.Errorf("node is syncing, at block %v of %v", uint64(progress.CurrentBlock), uint64(progress.HighestBlock))
+	}
+	if syncing {
+		return errors.New("node is syncing")
+	}
+	return nil
+}
+
+// checkEndpoints checks the nodes of all endpoints by checkNode, they must be on the chain with expected id
+func checkEndpoints(p *endpointPool, expected int64) error {
+	return p.each(func(ep *endpoint) error {
+		chainID, err := ep.ethClient.ChainID(context.Background())
+		if err != nil {
+			return err
+		}
+		return checkNode(ep.rpcClient, chainID, expected)
+	})
+}
+
+// logNodeStatus logs the status of node when chain status is recorded,
+// it is kept by the timestamp of chain status to compare in statistic
+func (e *ETH) logNodeStatus(timestamp int64, number *big.Int) {
+	status, err := queryNodeStatus(e.rpcClient())
+	if err != nil {
+		e.Logger.Errorf("query node status failed: %v", err)
+		return
+	}
+	e.Logger.Noticef("node status at block %v: %v", number, status)
+	if e.statuses == nil {
+		e.statuses = make(map[int64]*nodeStatus)
+	}
+	e.statuses[timestamp] = status
+}
+
+// logStatusChange logs the change of node status between the chain status of statistic
+func (e *ETH) logStatusChange(from, to int64) {
+	for _, line := range statusChange(e.statuses[from], e.statuses[to]) {
+		e.Logger.Notice(line)
+	}
+}
+
+// statusChange describes the change of txpool from start to end,
+// a growing txpool means the transactions are sent faster than the chain processes
+func statusChange(start, end *nodeStatus) []string {
+	if start == nil || end == nil {
+		return nil
+	}
+	var lines []string
+	if start.txpool != nil && end.txpool != nil {
+		lines = append(lines, fmt.Sprintf("txpool pending %v -> %v, queued %v -> %v",
+			uint64(start.txpool.Pending), uint64(end.txpool.Pending), uint64(start.txpool.Queued), uint64(end.txpool.Queued)))
+		if end.txpool.Pending+end.txpool.Queued > start.txpool.Pending+start.txpool.Queued {
+			lines = append(lines, "transactions are backing up in txpool, the sending rate is beyond what the chain processes")
+		}
+	}
+	if end.syncing != nil && *end.syncing {
+		lines = append(lines, fmt.Sprintf("node is syncing at the end, the statistic may be affected: %v", end))
+	}
+	return lines
+}
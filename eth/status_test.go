@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	fcom "github.com/hyperbench/hyperbench-common/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSyncingService is a node syncing blocks
+type fakeSyncingService struct{}
+
+func (s *fakeSyncingService) Syncing() map[string]hexutil.Uint64 {
+	return map[string]hexutil.Uint64{"startingBlock": 0, "currentBlock": 10, "highestBlock": 100}
+}
+
+func TestCheckNode(t *testing.T) {
+	server := rpc.NewServer()
+	assert.NoError(t, server.RegisterName("eth", &fakeSyncingService{}))
+	client := rpc.DialInProc(server)
+	err := checkNode(client, big.NewInt(1), 0)
+	assert.EqualError(t, err, "node is syncing, at block 10 of 100")
+
+	// the node without eth_syncing is checked by chain id only
+	client = rpc.DialInProc(rpc.NewServer())
+	assert.NoError(t, checkNode(client, big.NewInt(1), 0))
+	assert.NoError(t, checkNode(client, big.NewInt(1), 1))
+	assert.EqualError(t, checkNode(client, big.NewInt(1), 5), "chain id of node is 1, but 5 is expected")
+
+	// the unsupported methods are unknown in status
+	status, err := queryNodeStatus(client)
+	assert.NoError(t, err)
+	assert.Equal(t, "txpool unknown, syncing unknown, peers unknown, gas price unknown", status.String())
+}
+
+// fakeChainIDService is a synced node of chain id
+type fakeChainIDService struct {
+	id int64
+}
+
+func (s *fakeChainIDService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(s.id))
+}
+
+func (s *fakeChainIDService) Syncing() bool {
+	return false
+}
+
+func TestCheckEndpoints(t *testing.T) {
+	nodes := make([]*httptest.Server, 3)
+	urls := make([]string, len(nodes))
+	for i := range nodes {
+		server := rpc.NewServer()
+		assert.NoError(t, server.RegisterName("eth", &fakeChainIDService{id: int64(1 + i/2)}))
+		nodes[i] = httptest.NewServer(server)
+		defer nodes[i].Close()
+		urls[i] = nodes[i].URL
+	}
+	p, err := newEndpointPool(urls[:2], 0, 0, fcom.GetLogger("eth"))
+	assert.NoError(t, err)
+	assert.NoError(t, checkEndpoints(p, 1))
+
+	// the node on another chain is refused even if it is not bound
+	p, err = newEndpointPool(urls, 0, 0, fcom.GetLogger("eth"))
+	assert.NoError(t, err)
+	assert.EqualError(t, checkEndpoints(p, 1), urls[2]+": chain id of node is 2, but 1 is expected")
+
+	// the unreachable node is left to failover
+	nodes[2].Close()
+	p, err = newEndpointPool(urls, 0, 0, fcom.GetLogger("eth"))
+	assert.NoError(t, err)
+	assert.NoError(t, checkEndpoints(p, 1))
+}
+
+func TestNodeStatus(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	chain, err := newSimulatedChain(map[string]*ecdsa.PrivateKey{"main": key}, simulatedConfig{txs: 10}, fcom.GetLogger("eth"))
+	assert.NoError(t, err)
+	defer chain.close()
+	client := rpc.DialInProc(chain.server)
+	assert.NoError(t, checkNode(client, chain.chainID, chain.chainID.Int64()))
+
+	to := common.HexToAddress("0x74d366e0649a91395bb122c005917644382b9452")
+	tx, err := types.SignNewTx(key, chain.signer, &types.LegacyTx{Nonce: 0, GasPrice: big.NewInt(params.GWei), Gas: params.TxGas, To: &to})
+	assert.NoError(t, err)
+	assert.NoError(t, chain.send(context.Background(), tx))
+	status, err := queryNodeStatus(client)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), uint64(status.txpool.Pending))
+	assert.False(t, *status.syncing)
+	assert.Equal(t, uint64(0), uint64(*status.peers))
+	assert.NotNil(t, status.gasPrice)
+
+	info := status.info()
+	assert.Equal(t, uint64(1), info["pending"])
+	assert.Equal(t, false, info["syncing"])
+	assert.NotContains(t, info, "currentBlock")
+
+	// the growth of txpool is compared in statistic
+	assert.Equal(t, []string{
+		"txpool pending 0 -> 1, queued 0 -> 0",
+		"transactions are backing up in txpool, the sending rate is beyond what the chain processes",
+	}, statusChange(&nodeStatus{txpool: &txpoolStatus{}}, status))
+	assert.Len(t, statusChange(status, status), 1)
+	assert.Nil(t, statusChange(nil, status))
+}
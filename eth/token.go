@@ -0,0 +1,205 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide the ERC-20 token transfer workload
+ * @file token.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+//go:generate go run token/gen.go
+
+const (
+	// tokenABI is the abi of the bundled ERC-20 token, its bytecode tokenBIN is assembled from token/ERC20.easm
+	tokenABI = `[{"inputs":[{"name":"supply","type":"uint256"}],"stateMutability":"nonpayable","type":"constructor"},
+{"inputs":[],"name":"name","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+{"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+{"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"},
+{"inputs":[],"name":"totalSupply","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"name":"","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"name":"","type":"address"},{"name":"","type":"address"}],"name":"allowance","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"name":"transfer","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},
+{"inputs":[{"name":"spender","type":"address"},{"name":"amount","type":"uint256"}],"name":"approve","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},
+{"inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"name":"transferFrom","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},
+{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"to","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Transfer","type":"event"},
+{"anonymous":false,"inputs":[{"indexed":true,"name":"owner","type":"address"},{"indexed":true,"name":"spender","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Approval","type":"event"}]`
+	// tokenTransferSig is the signature of transfer function of ERC-20
+	tokenTransferSig = "transfer(address,uint256)"
+)
+
+var (
+	// tokenSupply is the supply of bundled token minted to the main account
+	tokenSupply = new(big.Int).Lsh(common.Big1, 128)
+	// defaultTokenFund is the amount of token funded to each account, one million tokens of 18 decimals
+	defaultTokenFund = new(big.Int).Mul(big.NewInt(1000000), big.NewInt(1e18))
+	// tokenParsed is the parsed abi of bundled token, it is used to transfer any ERC-20 token
+	tokenParsed, _ = abi.JSON(strings.NewReader(tokenABI))
+)
+
+// tokenOption is the option of token mode
+type tokenOption struct {
+	// path is the directory of the configured ERC-20 token, the bundled token is used if it is empty
+	path string
+	args []interface{}
+	fund *big.Int
+}
+
+// parseTokenOption parses the client options `token`, `tokenargs` and `tokenfund`,
+// nil is returned if token mode is disabled
+func parseTokenOption(options map[string]interface{}) (*tokenOption, error) {
+	op := &tokenOption{fund: defaultTokenFund}
+	switch value := options["token"].(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		if !value {
+			return nil, nil
+		}
+	case string:
+		op.path = value
+	default:
+		return nil, errors.New("option `token` type error: " + reflect.TypeOf(value).Name())
+	}
+	if args, ok := options["tokenargs"]; ok {
+		if op.args, ok = luaList(args); !ok {
+			return nil, errors.New("option `tokenargs` type error: " + reflect.TypeOf(args).Name())
+		}
+	}
+	if fund, ok := options["tokenfund"]; ok {
+		var err error
+		op.fund, err = parseInt(fund)
+		if err != nil || op.fund.Sign() < 0 {
+			return nil, fmt.Errorf("option `tokenfund` error: invalid amount %v", fund)
+		}
+	}
+	return op, nil
+}
+
+// deployToken deploys the ERC-20 token and waits for it created, the accounts are funded later by fundToken
+func (e *ETH) deployToken(op *tokenOption) error {
+	parsed, bin, args := tokenParsed, tokenBIN, []interface{}{tokenSupply}
+	if op.path != "" {
+		artifacts, err := loadArtifacts(op.path)
+		if err != nil {
+			return err
+		}
+		main, err := mainArtifact(artifacts, "")
+		if err != nil {
+			return err
+		}
+		if parsed, err = parseABI(main.abi); err != nil {
+			return err
+		}
+		if args, err = abiArgs(parsed.Constructor.Inputs, op.args); err != nil {
+			return fmt.Errorf("constructor of %v: %v", main.name, err)
+		}
+		if bin, err = e.linkArtifact(main, artifacts, make(libraries)); err != nil {
+			return err
+		}
+	}
+	address, tx, err := e.deploy(parsed, bin, args...)
+	if err != nil {
+		return err
+	}
+	if err = e.waitDeployed(address, tx); err != nil {
+		return err
+	}
+	e.token, e.tokenFund = &address, op.fund
+	e.Logger.Noticef("deploy token at %v", address.Hex())
+	return nil
+}
+
+// fundToken transfers the token from the main account to accounts by batch requests,
+// it returns after all transfers are confirmed
+func (e *ETH) fundToken(accounts []common.Address, fund *big.Int) error {
+	var hashes []common.Hash
+	for start := 0; start < len(accounts); start += instantBatch {
+		end := start + instantBatch
+		if end > len(accounts) {
+			end = len(accounts)
+		}
+		txs := make([]*types.Transaction, 0, end-start)
+		for _, account := range accounts[start:end] {
+			data := tokenTransfer(account, fund)
+			tx, err := e.signMain(e.token, big.NewInt(0), e.tokenGasLimit(e.mainSender.address, data), data)
+			if err != nil {
+				return err
+			}
+			txs = append(txs, tx)
+		}
+		for i, err := range e.sendBatch(txs) {
+			if err != nil {
+				return fmt.Errorf("fund token to %v failed: %v", accounts[start+i].Hex(), err)
+			}
+			hashes = append(hashes, txs[i].Hash())
+		}
+	}
+	if err := e.waitTxs(hashes); err != nil {
+		return err
+	}
+	e.Logger.Noticef("%v accounts are funded with %v tokens", len(accounts), fund)
+	return nil
+}
+
+// tokenAccounts returns the accounts and instant accounts to fund token, the main account is excluded
+func (e *ETH) tokenAccounts() []common.Address {
+	seen := map[common.Address]bool{e.mainSender.address: true}
+	var addresses []common.Address
+	for _, keys := range []map[string]*ecdsa.PrivateKey{e.Accounts, e.instants} {
+		for _, key := range keys {
+			if address := crypto.PubkeyToAddress(key.PublicKey); !seen[address] {
+				seen[address] = true
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return addresses
+}
+
+// tokenTransfer returns the input of transferring amount of token to account,
+// a negative amount is encoded in two's complement as abi does
+func tokenTransfer(to common.Address, amount *big.Int) []byte {
+	data := append([]byte{}, tokenParsed.Methods["transfer"].ID...)
+	data = append(data, common.LeftPadBytes(to.Bytes(), 32)...)
+	return append(data, math.U256Bytes(new(big.Int).Set(amount))...)
+}
+
+// tokenGasLimit returns the gas limit of transferring token with input data
+func (e *ETH) tokenGasLimit(from common.Address, data []byte) uint64 {
+	return e.gasLimit(transferGasKey, tokenTransferSig, ethereum.CallMsg{
+		From:  from,
+		To:    e.token,
+		Value: big.NewInt(0),
+		Data:  data,
+	})
+}
@@ -0,0 +1,162 @@
+//go:build ignore
+// +build ignore
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Assemble the bytecode of the bundled ERC-20 token
+ * @file gen.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+// gen assembles token/ERC20.easm and writes the bytecode to token_bin.go,
+// it is run by `go generate` in the directory of eth plugin and needs nothing but go:
+//
+//	go generate ./...
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+const (
+	source = "token/ERC20.easm"
+	output = "token_bin.go"
+)
+
+// tokenPattern matches the strings and other tokens of a line
+var tokenPattern = regexp.MustCompile(`"[^"]*"|\S+`)
+
+// section is the assembled code of a section, the labels are relative to the section
+type section struct {
+	code   []byte
+	labels map[string]int
+	// refs are the offsets of PUSH2 operands referring to labels
+	refs map[int]string
+}
+
+// assemble assembles the lines of section, the labels are resolved by resolve
+func assemble(lines []string) (*section, error) {
+	s := &section{labels: make(map[string]int), refs: make(map[int]string)}
+	for n, line := range lines {
+		if i := strings.Index(line, ";;"); i >= 0 {
+			line = line[:i]
+		}
+		for _, token := range tokenPattern.FindAllString(line, -1) {
+			var value []byte
+			switch {
+			case strings.HasSuffix(token, ":"):
+				s.labels[strings.TrimSuffix(token, ":")] = len(s.code)
+				s.code = append(s.code, byte(vm.JUMPDEST))
+				continue
+			case strings.HasPrefix(token, "@"):
+				s.code = append(s.code, byte(vm.PUSH2), 0, 0)
+				s.refs[len(s.code)-2] = token[1:]
+				continue
+			case strings.HasPrefix(token, `"`):
+				value = []byte(strings.Trim(token, `"`))
+			case token[0] >= '0' && token[0] <= '9':
+				number, ok := math.ParseBig256(token)
+				if !ok {
+					return nil, fmt.Errorf("line %v: invalid number %v", n+1, token)
+				}
+				if value = number.Bytes(); len(value) == 0 {
+					value = []byte{0}
+				}
+			default:
+				op := vm.StringToOp(strings.ToUpper(token))
+				if op.String() != strings.ToUpper(token) {
+					return nil, fmt.Errorf("line %v: unknown opcode %v", n+1, token)
+				}
+				s.code = append(s.code, byte(op))
+				continue
+			}
+			if len(value) == 0 || len(value) > 32 {
+				return nil, fmt.Errorf("line %v: %v is not 1 to 32 bytes", n+1, token)
+			}
+			s.code = append(s.code, byte(vm.PUSH1)+byte(len(value)-1))
+			s.code = append(s.code, value...)
+		}
+	}
+	return s, nil
+}
+
+// resolve fills the label references of section, extra are the labels outside of section
+func (s *section) resolve(extra map[string]int) error {
+	for offset, label := range s.refs {
+		value, ok := s.labels[label]
+		if !ok {
+			if value, ok = extra[label]; !ok {
+				return fmt.Errorf("unknown label %v", label)
+			}
+		}
+		copy(s.code[offset:offset+2], big.NewInt(int64(value)).FillBytes(make([]byte, 2)))
+	}
+	return nil
+}
+
+func main() {
+	src, err := ioutil.ReadFile(source)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sections := make(map[string][]string)
+	name := ""
+	for _, line := range strings.Split(string(src), "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, ".") {
+			name = trimmed[1:]
+			continue
+		}
+		sections[name] = append(sections[name], line)
+	}
+	runtime, err := assemble(sections["runtime"])
+	if err == nil {
+		err = runtime.resolve(nil)
+	}
+	if err != nil {
+		log.Fatalf("assemble runtime: %v", err)
+	}
+	constructor, err := assemble(sections["constructor"])
+	if err == nil {
+		err = constructor.resolve(map[string]int{"runtime": len(constructor.code), "runtimesize": len(runtime.code)})
+	}
+	if err != nil {
+		log.Fatalf("assemble constructor: %v", err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by token/gen.go from %v; DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(&buf, "package main\n\n// tokenBIN is the bytecode of the bundled ERC-20 token\n")
+	fmt.Fprintf(&buf, "const tokenBIN = %q\n", hex.EncodeToString(append(constructor.code, runtime.code...)))
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = ioutil.WriteFile(output, out, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
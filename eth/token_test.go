@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	fcom "github.com/hyperbench/hyperbench-common/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// tokenCall calls the view method of token and returns the integer result
+func tokenCall(t *testing.T, e *ETH, method string, args ...interface{}) *big.Int {
+	data, err := tokenParsed.Pack(method, args...)
+	assert.NoError(t, err)
+	out, err := e.ethClient().CallContract(context.Background(), ethereum.CallMsg{To: e.token, Data: data}, nil)
+	assert.NoError(t, err)
+	return new(big.Int).SetBytes(out)
+}
+
+// tokenSend sends the transaction calling method of token and waits for it confirmed
+func tokenSend(t *testing.T, e *ETH, s *sender, method string, args ...interface{}) error {
+	data, err := tokenParsed.Pack(method, args...)
+	assert.NoError(t, err)
+	tx, _, err := e.sendTx(s, e.token, big.NewInt(0), defaultGasLimit, data, nil, true)
+	assert.NoError(t, err)
+	return e.waitTxs([]common.Hash{tx.Hash()})
+}
+
+func TestParseTokenOption(t *testing.T) {
+	op, err := parseTokenOption(map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Nil(t, op)
+	op, err = parseTokenOption(map[string]interface{}{"token": false})
+	assert.NoError(t, err)
+	assert.Nil(t, op)
+	op, err = parseTokenOption(map[string]interface{}{"token": true})
+	assert.NoError(t, err)
+	assert.Equal(t, "", op.path)
+	assert.Equal(t, defaultTokenFund, op.fund)
+	op, err = parseTokenOption(map[string]interface{}{"token": "erc20", "tokenargs": []interface{}{"100"}, "tokenfund": int64(10)})
+	assert.NoError(t, err)
+	assert.Equal(t, "erc20", op.path)
+	assert.Equal(t, []interface{}{"100"}, op.args)
+	assert.Equal(t, int64(10), op.fund.Int64())
+	_, err = parseTokenOption(map[string]interface{}{"token": float64(1)})
+	assert.Error(t, err)
+	_, err = parseTokenOption(map[string]interface{}{"token": true, "tokenfund": "-1"})
+	assert.Error(t, err)
+}
+
+func TestToken(t *testing.T) {
+	client, stop := newSimulatedClient(t, storageABI, storageBIN)
+	defer stop()
+	client.Options = map[string]interface{}{"token": true, "tokenfund": int64(1000)}
+	assert.NoError(t, client.DeployContract())
+	assert.NotNil(t, client.token)
+	assert.Equal(t, tokenSupply, tokenCall(t, client, "totalSupply"))
+	assert.Equal(t, int64(18), tokenCall(t, client, "decimals").Int64())
+	for method, expected := range map[string]string{"name": "Hyperbench Token", "symbol": "HBT"} {
+		data, err := tokenParsed.Pack(method)
+		assert.NoError(t, err)
+		out, err := client.ethClient().CallContract(context.Background(), ethereum.CallMsg{To: client.token, Data: data}, nil)
+		assert.NoError(t, err)
+		ret, err := tokenParsed.Unpack(method, out)
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{expected}, ret)
+	}
+
+	// accounts are funded by master and the token is shipped to workers
+	ctx, err := client.GetContext()
+	assert.NoError(t, err)
+	msg := &Msg{}
+	assert.NoError(t, json.Unmarshal([]byte(ctx), msg))
+	assert.Equal(t, client.token, msg.Token)
+	main, other := fromAddress, crypto.PubkeyToAddress(accounts["other"].PublicKey)
+	assert.Equal(t, int64(1000), tokenCall(t, client, "balanceOf", other).Int64())
+
+	res := client.Transfer(fcom.Transfer{From: "other", To: main.Hex(), Amount: 10})
+	assert.Equal(t, fcom.Success, res.Status)
+	res = client.Confirm(res)
+	assert.Equal(t, fcom.Confirm, res.Status)
+	assert.Equal(t, int64(990), tokenCall(t, client, "balanceOf", other).Int64())
+	left := new(big.Int).Sub(tokenSupply, big.NewInt(990))
+	assert.Equal(t, left, tokenCall(t, client, "balanceOf", main))
+
+	// the transfer over balance reverts
+	res = client.Transfer(fcom.Transfer{From: "other", To: main.Hex(), Amount: 991})
+	assert.Equal(t, fcom.Success, res.Status)
+	res = client.Confirm(res)
+	assert.Equal(t, fcom.Failure, res.Status)
+	assert.Equal(t, "ERC20: insufficient balance", res.Ret[0].(map[string]interface{})[revertKey])
+
+	// the allowance is spent by transferFrom
+	spender := client.senders.byAddress[other]
+	assert.NoError(t, tokenSend(t, client, client.mainSender, "approve", other, big.NewInt(50)))
+	assert.NoError(t, tokenSend(t, client, spender, "transferFrom", main, other, big.NewInt(30)))
+	assert.Equal(t, int64(20), tokenCall(t, client, "allowance", main, other).Int64())
+	assert.Equal(t, int64(1020), tokenCall(t, client, "balanceOf", other).Int64())
+	assert.Error(t, tokenSend(t, client, spender, "transferFrom", main, other, big.NewInt(21)))
+}
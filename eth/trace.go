@@ -0,0 +1,254 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide the sampled tracing of confirmed transactions by debug_traceTransaction
+ * @file trace.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	// traceKey is the key of trace summary in the result of confirm
+	traceKey = "trace"
+	// tracerStruct traces by the struct logger of opcodes, tracerCall traces by callTracer of calls
+	tracerStruct = "struct"
+	tracerCall   = "call"
+	// traceExt is the extension of files keeping the trace summaries of a label
+	traceExt = ".trace"
+)
+
+// traceMu serializes the writing of trace files shared by vms
+var traceMu sync.Mutex
+
+// traceOption is the option `trace`
+type traceOption struct {
+	// rate is the ratio of confirmed transactions traced
+	rate   float64
+	tracer string
+	// dir is where the summaries are dumped to file per label, they are returned in result if it is empty
+	dir string
+}
+
+// parseTraceOption parses the option `trace`, nil is returned if tracing is disabled
+func parseTraceOption(value interface{}) (*traceOption, error) {
+	if enabled, ok := value.(bool); ok {
+		if !enabled {
+			return nil, nil
+		}
+		return &traceOption{rate: 1, tracer: tracerStruct}, nil
+	}
+	table, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("option `trace` type error: " + reflect.TypeOf(value).Name())
+	}
+	op := &traceOption{rate: 1, tracer: tracerStruct}
+	for k, v := range table {
+		key, _ := k.(string)
+		var ok bool
+		switch key {
+		case "rate":
+			op.rate, ok = v.(float64)
+			ok = ok && op.rate > 0 && op.rate <= 1
+		case "tracer":
+			op.tracer, ok = v.(string)
+			ok = ok && (op.tracer == tracerStruct || op.tracer == tracerCall)
+		case "dir":
+			op.dir, ok = v.(string)
+		default:
+			return nil, fmt.Errorf("option `trace` error: unknown key %v", k)
+		}
+		if !ok {
+			return nil, fmt.Errorf("option `trace` error: invalid %v: %v", k, v)
+		}
+	}
+	return op, nil
+}
+
+// structLog is an opcode executed in the result of struct logger
+type structLog struct {
+	Op      string `json:"op"`
+	Gas     uint64 `json:"gas"`
+	GasCost uint64 `json:"gasCost"`
+	Depth   int    `json:"depth"`
+}
+
+// structTrace is the result of debug_traceTransaction with struct logger
+type structTrace struct {
+	Gas        uint64      `json:"gas"`
+	Failed     bool        `json:"failed"`
+	StructLogs []structLog `json:"structLogs"`
+}
+
+// callFrame is a call in the result of debug_traceTransaction with callTracer
+type callFrame struct {
+	Type    string         `json:"type"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Error   string         `json:"error"`
+	Calls   []callFrame    `json:"calls"`
+}
+
+// traceSummary is the summary of the trace of transaction
+type traceSummary struct {
+	Tracer string `json:"tracer"`
+	Gas    uint64 `json:"gas"`
+	Failed bool   `json:"failed"`
+	// GasByOp is the gas spent by each opcode, the gas forwarded to the callee is not counted in calls
+	GasByOp map[string]uint64 `json:"gasByOp,omitempty"`
+	Sload   int               `json:"sload"`
+	Sstore  int               `json:"sstore"`
+	// Calls counts the calls by type, the top call included
+	Calls map[string]int `json:"calls,omitempty"`
+	// Depth is the max depth of calls, the top call is depth 1
+	Depth int `json:"depth"`
+}
+
+// summarizeStructLogs summarizes the trace of struct logger, the gas of opcode is the decrease of gas to the next one
+// in the same call, and the gas forwarded to the callee is subtracted from the cost of opcodes entering calls
+func summarizeStructLogs(t *structTrace) *traceSummary {
+	s := &traceSummary{Tracer: tracerStruct, Gas: t.Gas, Failed: t.Failed, GasByOp: make(map[string]uint64)}
+	for i, l := range t.StructLogs {
+		cost := l.GasCost
+		if i+1 < len(t.StructLogs) {
+			next := t.StructLogs[i+1]
+			switch {
+			case next.Depth == l.Depth && l.Gas >= next.Gas:
+				cost = l.Gas - next.Gas
+			case next.Depth > l.Depth && cost >= next.Gas:
+				cost -= next.Gas
+			}
+		}
+		s.GasByOp[l.Op] += cost
+		switch l.Op {
+		case "SLOAD":
+			s.Sload++
+		case "SSTORE":
+			s.Sstore++
+		}
+		if l.Depth > s.Depth {
+			s.Depth = l.Depth
+		}
+	}
+	if s.Depth == 0 {
+		// the transaction without code is a call of depth 1
+		s.Depth = 1
+	}
+	return s
+}
+
+// summarizeCalls summarizes the trace of callTracer
+func summarizeCalls(root *callFrame) *traceSummary {
+	s := &traceSummary{Tracer: tracerCall, Gas: uint64(root.GasUsed), Failed: root.Error != "", Calls: make(map[string]int)}
+	var walk func(f *callFrame, depth int)
+	walk = func(f *callFrame, depth int) {
+		s.Calls[f.Type]++
+		if depth > s.Depth {
+			s.Depth = depth
+		}
+		for i := range f.Calls {
+			walk(&f.Calls[i], depth+1)
+		}
+	}
+	walk(root, 1)
+	return s
+}
+
+// traceTx traces the transaction by debug_traceTransaction and summarizes the trace
+func (e *ETH) traceTx(hash common.Hash, tracer string) (*traceSummary, error) {
+	config := map[string]interface{}{"disableStack": true, "disableStorage": true}
+	if tracer == tracerCall {
+		config = map[string]interface{}{"tracer": "callTracer"}
+	}
+	var raw json.RawMessage
+	_, err := e.endpoints.call(func(ep *endpoint) error {
+		return ep.rpcClient.CallContext(context.Background(), &raw, "debug_traceTransaction", hash, config)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if tracer == tracerCall {
+		root := &callFrame{}
+		if err = json.Unmarshal(raw, root); err != nil {
+			return nil, err
+		}
+		return summarizeCalls(root), nil
+	}
+	t := &structTrace{}
+	if err = json.Unmarshal(raw, t); err != nil {
+		return nil, err
+	}
+	return summarizeStructLogs(t), nil
+}
+
+// sampleTrace traces the confirmed transaction if it is sampled, the summary is recorded in info
+// or appended to the file of label in the dir of option
+func (e *ETH) sampleTrace(label string, hash common.Hash, info map[string]interface{}) {
+	op := e.op.trace
+	if op == nil || e.random.Float64() >= op.rate {
+		return
+	}
+	summary, err := e.traceTx(hash, op.tracer)
+	if err != nil {
+		e.Logger.Errorf("trace transaction %v failed: %v", hash.Hex(), err)
+		return
+	}
+	if op.dir == "" {
+		info[traceKey] = summary
+		return
+	}
+	if err = appendTrace(op.dir, label, hash, summary); err != nil {
+		e.Logger.Errorf("dump trace of %v failed: %v", hash.Hex(), err)
+	}
+}
+
+// appendTrace appends the summary to the file of label in dir, one json per line
+func appendTrace(dir, label string, hash common.Hash, summary *traceSummary) error {
+	line, err := json.Marshal(struct {
+		Hash common.Hash `json:"hash"`
+		*traceSummary
+	}{hash, summary})
+	if err != nil {
+		return err
+	}
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := strings.NewReplacer("/", "_", string(filepath.Separator), "_").Replace(label)
+	f, err := os.OpenFile(filepath.Join(dir, name+traceExt), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fcom "github.com/hyperbench/hyperbench-common/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTraceOption(t *testing.T) {
+	op, err := parseTraceOption(false)
+	assert.NoError(t, err)
+	assert.Nil(t, op)
+	op, err = parseTraceOption(true)
+	assert.NoError(t, err)
+	assert.Equal(t, &traceOption{rate: 1, tracer: tracerStruct}, op)
+	op, err = parseTraceOption(map[interface{}]interface{}{"rate": 0.1, "tracer": "call", "dir": "traces"})
+	assert.NoError(t, err)
+	assert.Equal(t, &traceOption{rate: 0.1, tracer: tracerCall, dir: "traces"}, op)
+	_, err = parseTraceOption(map[interface{}]interface{}{"rate": float64(2)})
+	assert.Error(t, err)
+	_, err = parseTraceOption(map[interface{}]interface{}{"tracer": "prestate"})
+	assert.Error(t, err)
+	_, err = parseTraceOption("struct")
+	assert.Error(t, err)
+}
+
+func TestSummarizeTrace(t *testing.T) {
+	// the gas forwarded by CALL is spent in the callee
+	s := summarizeStructLogs(&structTrace{Gas: 30000, StructLogs: []structLog{
+		{Op: "PUSH1", Gas: 10000, GasCost: 3, Depth: 1},
+		{Op: "SLOAD", Gas: 9997, GasCost: 2100, Depth: 1},
+		{Op: "CALL", Gas: 7897, GasCost: 7700, Depth: 1},
+		{Op: "SSTORE", Gas: 5000, GasCost: 2900, Depth: 2},
+		{Op: "STOP", Gas: 2100, GasCost: 0, Depth: 2},
+		{Op: "STOP", Gas: 4197, GasCost: 0, Depth: 1},
+	}})
+	assert.Equal(t, map[string]uint64{"PUSH1": 3, "SLOAD": 2100, "CALL": 2700, "SSTORE": 2900, "STOP": 0}, s.GasByOp)
+	assert.Equal(t, 1, s.Sload)
+	assert.Equal(t, 1, s.Sstore)
+	assert.Equal(t, 2, s.Depth)
+
+	root := &callFrame{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"type":"CALL","gasUsed":"0x5208","calls":[
+		{"type":"STATICCALL","gasUsed":"0x10"},{"type":"CALL","gasUsed":"0x20","calls":[{"type":"CREATE","gasUsed":"0x30"}]}]}`), root))
+	s = summarizeCalls(root)
+	assert.Equal(t, uint64(21000), s.Gas)
+	assert.Equal(t, map[string]int{"CALL": 2, "STATICCALL": 1, "CREATE": 1}, s.Calls)
+	assert.Equal(t, 3, s.Depth)
+}
+
+func TestTrace(t *testing.T) {
+	client, stop := newSimulatedClient(t, storageABI, storageBIN)
+	defer stop()
+	assert.NoError(t, client.DeployContract())
+
+	// the summary is returned in result
+	assert.NoError(t, client.Option(fcom.Option{"trace": true}))
+	res := client.Confirm(client.Invoke(fcom.Invoke{Func: "set", Args: []interface{}{float64(42)}}))
+	assert.Equal(t, fcom.Confirm, res.Status)
+	info := res.Ret[0].(map[string]interface{})
+	s := info[traceKey].(*traceSummary)
+	assert.Equal(t, info["gasUsed"], s.Gas)
+	assert.Equal(t, 1, s.Sstore)
+	assert.Equal(t, 1, s.Depth)
+	assert.True(t, s.GasByOp["SSTORE"] > 0)
+
+	// the summaries are dumped to the file of label
+	dir, err := ioutil.TempDir("", "trace")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, client.Option(fcom.Option{"trace": map[interface{}]interface{}{"dir": dir}}))
+	for i := 0; i < 2; i++ {
+		res = client.Confirm(client.Invoke(fcom.Invoke{Func: "set", Args: []interface{}{float64(i)}}))
+		assert.NotContains(t, res.Ret[0], traceKey)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "set"+traceExt))
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[1], res.UID)
+
+	// the confirmation is not affected if the node does not support the tracer
+	assert.NoError(t, client.Option(fcom.Option{"trace": map[interface{}]interface{}{"tracer": "call"}}))
+	res = client.Confirm(client.Invoke(fcom.Invoke{Func: "set", Args: []interface{}{float64(1)}}))
+	assert.Equal(t, fcom.Confirm, res.Status)
+	assert.NotContains(t, res.Ret[0], traceKey)
+}
@@ -0,0 +1,197 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide accounts derived from mnemonic and loaded from plain key file
+ * @file wallet.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	ethaccounts "github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// defaultHDPath is the bip-44 path of ethereum accounts, the index of account is appended
+	defaultHDPath = "m/44'/60'/0'/0"
+	// hardenedOffset is the first index of hardened child key
+	hardenedOffset = 0x80000000
+)
+
+// accountKey is the private key of account with its alias
+type accountKey struct {
+	alias string
+	key   *ecdsa.PrivateKey
+}
+
+// accountAlias returns the alias of account, it is the lower hex address without 0x
+// as the keystore file names
+func accountAlias(key *ecdsa.PrivateKey) string {
+	return strings.ToLower(strings.TrimPrefix(crypto.PubkeyToAddress(key.PublicKey).Hex(), "0x"))
+}
+
+// mnemonicSeed returns the bip-39 seed of mnemonic and passphrase,
+// the mnemonic is refused if it has a word out of the english wordlist or a wrong checksum
+func mnemonicSeed(mnemonic, passphrase string) ([]byte, error) {
+	words := strings.Join(strings.Fields(mnemonic), " ")
+	if _, err := bip39.EntropyFromMnemonic(words); err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %v", err)
+	}
+	password := norm.NFKD.String(words)
+	salt := norm.NFKD.String("mnemonic" + passphrase)
+	return pbkdf2.Key([]byte(password), []byte(salt), 2048, 64, sha512.New), nil
+}
+
+// hdKey is the extended private key of bip-32
+type hdKey struct {
+	key   *big.Int
+	chain []byte
+}
+
+// masterKey returns the master key of seed
+func masterKey(seed []byte) (*hdKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key := new(big.Int).SetBytes(sum[:32])
+	if key.Sign() == 0 || key.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, errors.New("invalid master key")
+	}
+	return &hdKey{key: key, chain: sum[32:]}, nil
+}
+
+// child returns the child private key with index
+func (k *hdKey) child(index uint32) (*hdKey, error) {
+	var data []byte
+	if index >= hardenedOffset {
+		data = append([]byte{0}, math.PaddedBigBytes(k.key, 32)...)
+	} else {
+		x, y := crypto.S256().ScalarBaseMult(math.PaddedBigBytes(k.key, 32))
+		data = crypto.CompressPubkey(&ecdsa.PublicKey{Curve: crypto.S256(), X: x, Y: y})
+	}
+	data = append(data, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[len(data)-4:], index)
+
+	mac := hmac.New(sha512.New, k.chain)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	n := crypto.S256().Params().N
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("invalid child key %v", index)
+	}
+	key := il.Add(il, k.key)
+	key.Mod(key, n)
+	if key.Sign() == 0 {
+		return nil, fmt.Errorf("invalid child key %v", index)
+	}
+	return &hdKey{key: key, chain: sum[32:]}, nil
+}
+
+// deriveKey derives the key at path from the master key of seed
+func deriveKey(seed []byte, path ethaccounts.DerivationPath) (*hdKey, error) {
+	key, err := masterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range path {
+		if key, err = key.child(index); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// deriveAccounts derives count accounts from mnemonic, the account `i` uses the key at `path/i`
+func deriveAccounts(mnemonic, passphrase, path string, count int) ([]accountKey, error) {
+	if path == "" {
+		path = defaultHDPath
+	}
+	indexes, err := ethaccounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := mnemonicSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	parent, err := deriveKey(seed, indexes)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]accountKey, 0, count)
+	for i := 0; i < count; i++ {
+		child, err := parent.child(uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		key, err := crypto.ToECDSA(math.PaddedBigBytes(child.key, 32))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, accountKey{alias: accountAlias(key), key: key})
+	}
+	return keys, nil
+}
+
+// loadKeyFile loads the private keys in hex, one per line in form of `key` or `alias=key`,
+// empty lines and lines beginning with `#` are ignored
+func loadKeyFile(file string) ([]accountKey, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var keys []accountKey
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		alias := ""
+		if i := strings.Index(text, "="); i >= 0 {
+			alias, text = strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:])
+		}
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(text, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key at line %v of %v: %v", line, file, err)
+		}
+		if alias == "" {
+			alias = accountAlias(key)
+		}
+		keys = append(keys, accountKey{alias: alias, key: key})
+	}
+	return keys, scanner.Err()
+}
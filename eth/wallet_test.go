@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ethaccounts "github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveAccounts(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	keys, err := deriveAccounts(mnemonic, "", "", 2)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+	assert.Equal(t, "0x9858EfFD232B4033E47d90003D41EC34EcaEda94", crypto.PubkeyToAddress(keys[0].key.PublicKey).Hex())
+	assert.Equal(t, "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0", crypto.PubkeyToAddress(keys[1].key.PublicKey).Hex())
+	assert.Equal(t, "9858effd232b4033e47d90003d41ec34ecaeda94", keys[0].alias)
+
+	// deterministic
+	again, err := deriveAccounts(mnemonic, "", "m/44'/60'/0'/0", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, keys[0].key.D, again[0].key.D)
+
+	_, err = deriveAccounts(mnemonic, "", "m/44'/60'/x", 1)
+	assert.Error(t, err)
+
+	// a word out of the wordlist and a wrong checksum are refused
+	_, err = deriveAccounts("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandonn", "", "", 1)
+	assert.Error(t, err)
+	_, err = deriveAccounts("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", "", "", 1)
+	assert.Error(t, err)
+}
+
+// TestMnemonicSeed checks the test vectors of bip-39 with passphrase TREZOR
+func TestMnemonicSeed(t *testing.T) {
+	vectors := []struct {
+		mnemonic string
+		seed     string
+	}{
+		{
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			"legal winner thank year wave sausage worth useful legal winner thank yellow",
+			"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+	}
+	for _, v := range vectors {
+		seed, err := mnemonicSeed(v.mnemonic, "TREZOR")
+		assert.NoError(t, err)
+		assert.Equal(t, v.seed, hex.EncodeToString(seed))
+	}
+}
+
+// TestDeriveKey checks the test vector 1 of bip-32
+func TestDeriveKey(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	vectors := []struct {
+		path  string
+		key   string
+		chain string
+	}{
+		{"m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35", "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508"},
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea", "47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368", "2a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca", "04466b9cc8e161e966409ca52986c584f07e9dc81f735db683c3ff6ec7b1503f"},
+	}
+	for _, v := range vectors {
+		var path ethaccounts.DerivationPath
+		if v.path != "m" {
+			var err error
+			path, err = ethaccounts.ParseDerivationPath(v.path)
+			assert.NoError(t, err)
+		}
+		key, err := deriveKey(seed, path)
+		assert.NoError(t, err)
+		assert.Equal(t, v.key, hex.EncodeToString(math.PaddedBigBytes(key.key, 32)), v.path)
+		assert.Equal(t, v.chain, hex.EncodeToString(key.chain), v.path)
+	}
+}
+
+func TestLoadKeyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keys")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "keys.txt")
+	content := "# test keys\n" +
+		"0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318\n\n" +
+		"alice = 0x8da4ef21b864d2cc526dbdb2a120bd2874c36c9d0a1fb7f8c63d7f7a8b41de8f\n"
+	assert.NoError(t, ioutil.WriteFile(file, []byte(content), 0600))
+
+	keys, err := loadKeyFile(file)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+	assert.Equal(t, "2c7536e3605d9c16a7a3d7b1898e529396a65c23", keys[0].alias)
+	assert.Equal(t, "alice", keys[1].alias)
+
+	assert.NoError(t, ioutil.WriteFile(file, []byte("zz\n"), 0600))
+	_, err = loadKeyFile(file)
+	assert.Error(t, err)
+}
@@ -0,0 +1,340 @@
+package main
+
+/**
+ *  Copyright (C) 2021 HyperBench.
+ *  SPDX-License-Identifier: Apache-2.0
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ * @brief Provide confirmation of transactions by the subscription of new heads
+ * @file watcher.go
+ * @author: agent
+ * @date 2026-10-18
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/op/go-logging"
+)
+
+// modes of confirmation
+const (
+	// confirmPoll polls the receipt of each transaction
+	confirmPoll = "poll"
+	// confirmSubscribe resolves transactions by the blocks of new heads subscription
+	confirmSubscribe = "subscribe"
+)
+
+const (
+	// recentBlocks is the number of blocks whose transactions are remembered,
+	// transactions confirmed after their blocks are processed are resolved by them
+	recentBlocks = 256
+	// maxCatchUp is the max number of skipped blocks fetched when a head arrives
+	maxCatchUp = 64
+	// resubscribeDelay is the delay before resubscribing after the subscription dropped
+	resubscribeDelay = time.Second
+)
+
+// inclusion is the transaction included in block
+type inclusion struct {
+	receipt *receipt
+	hash    common.Hash
+	header  *types.Header
+}
+
+// waiter is the transaction waited by Confirm, done is closed to notify all the waiters of transaction
+type waiter struct {
+	done chan struct{}
+	inc  *inclusion
+	// count is the number of waiting calls
+	count int
+}
+
+// blockTxs is the header and transaction hashes of block
+type blockTxs struct {
+	hash   common.Hash
+	header *types.Header
+	txs    []common.Hash
+}
+
+// headWatcher subscribes new heads by websocket and fetches the transaction hashes
+// of each block once, the transactions waited by Confirm are resolved in batch
+type headWatcher struct {
+	client *rpc.Client
+	logger *logging.Logger
+
+	mu      sync.Mutex
+	healthy bool
+	last    *big.Int
+	waiters map[common.Hash]*waiter
+	// recent maps the transactions in recent blocks to their blocks
+	recent map[common.Hash]*blockTxs
+	order  []*blockTxs
+}
+
+var (
+	watchersMu sync.Mutex
+	// watchers are shared by all vms of worker, keyed by websocket url
+	watchers = make(map[string]*headWatcher)
+)
+
+// getHeadWatcher returns the watcher of websocket url, it is created on first use
+func getHeadWatcher(url string, logger *logging.Logger) (*headWatcher, error) {
+	watchersMu.Lock()
+	defer watchersMu.Unlock()
+	if w, ok := watchers[url]; ok {
+		return w, nil
+	}
+	client, err := dialEndpoint(url)
+	if err != nil {
+		return nil, err
+	}
+	w := newHeadWatcher(client, logger)
+	heads := make(chan *types.Header, maxCatchUp)
+	sub, err := client.EthSubscribe(context.Background(), heads, "newHeads")
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	w.healthy = true
+	go w.run(sub, heads)
+	watchers[url] = w
+	return w, nil
+}
+
+func newHeadWatcher(client *rpc.Client, logger *logging.Logger) *headWatcher {
+	return &headWatcher{
+		client:  client,
+		logger:  logger,
+		waiters: make(map[common.Hash]*waiter),
+		recent:  make(map[common.Hash]*blockTxs),
+	}
+}
+
+// run processes new heads and resubscribes when the subscription dropped
+func (w *headWatcher) run(sub ethereum.Subscription, heads chan *types.Header) {
+	for {
+		select {
+		case head := <-heads:
+			w.process(head)
+		case err := <-sub.Err():
+			w.logger.Errorf("new heads subscription dropped: %v", err)
+			w.setHealthy(false)
+			for {
+				time.Sleep(resubscribeDelay)
+				sub, err = w.client.EthSubscribe(context.Background(), heads, "newHeads")
+				if err == nil {
+					break
+				}
+				w.logger.Errorf("resubscribe new heads failed: %v", err)
+			}
+			w.setHealthy(true)
+		}
+	}
+}
+
+func (w *headWatcher) setHealthy(healthy bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.healthy = healthy
+}
+
+// isHealthy returns whether the subscription is alive, polling is used otherwise
+func (w *headWatcher) isHealthy() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.healthy
+}
+
+// process fetches the blocks from the last processed one to the head
+// and resolves the transactions waited in them
+func (w *headWatcher) process(head *types.Header) {
+	from := new(big.Int).Add(head.Number, common.Big1)
+	w.mu.Lock()
+	if w.last != nil && w.last.Cmp(head.Number) < 0 {
+		from.Add(w.last, common.Big1)
+	}
+	w.mu.Unlock()
+	if min := new(big.Int).Sub(head.Number, big.NewInt(maxCatchUp-1)); from.Cmp(min) < 0 {
+		from = min
+	}
+
+	for n := from; n.Cmp(head.Number) < 0; n = new(big.Int).Add(n, common.Big1) {
+		block, err := w.block("eth_getBlockByNumber", hexutil.EncodeBig(n))
+		if err != nil {
+			w.logger.Errorf("query block %v failed: %v", n, err)
+			continue
+		}
+		w.resolve(block)
+	}
+	block, err := w.block("eth_getBlockByHash", head.Hash())
+	if err != nil {
+		w.logger.Errorf("query block %v failed: %v", head.Hash().String(), err)
+		return
+	}
+	w.resolve(block)
+}
+
+// block fetches the header and transaction hashes of block
+func (w *headWatcher) block(method string, arg interface{}) (*blockTxs, error) {
+	var raw json.RawMessage
+	err := w.client.CallContext(context.Background(), &raw, method, arg, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, ethereum.NotFound
+	}
+	header := &types.Header{}
+	if err = json.Unmarshal(raw, header); err != nil {
+		return nil, err
+	}
+	var body struct {
+		Hash         common.Hash   `json:"hash"`
+		Transactions []common.Hash `json:"transactions"`
+	}
+	if err = json.Unmarshal(raw, &body); err != nil {
+		return nil, err
+	}
+	return &blockTxs{hash: body.Hash, header: header, txs: body.Transactions}, nil
+}
+
+// resolve remembers the transactions of block and delivers the receipts of
+// waited transactions, the receipts are fetched by one batch request
+func (w *headWatcher) resolve(block *blockTxs) {
+	w.mu.Lock()
+	if w.last == nil || w.last.Cmp(block.header.Number) < 0 {
+		w.last = block.header.Number
+	}
+	if len(w.order) >= recentBlocks {
+		evicted := w.order[0]
+		for _, hash := range evicted.txs {
+			if w.recent[hash] == evicted {
+				delete(w.recent, hash)
+			}
+		}
+		w.order = w.order[1:]
+	}
+	w.order = append(w.order, block)
+	var matched []common.Hash
+	for _, hash := range block.txs {
+		w.recent[hash] = block
+		if _, ok := w.waiters[hash]; ok {
+			matched = append(matched, hash)
+		}
+	}
+	w.mu.Unlock()
+	if len(matched) == 0 {
+		return
+	}
+
+	raws := make([]json.RawMessage, len(matched))
+	batch := make([]rpc.BatchElem, len(matched))
+	for i, hash := range matched {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []interface{}{hash},
+			Result: &raws[i],
+		}
+	}
+	err := w.client.BatchCallContext(context.Background(), batch)
+	if err != nil {
+		w.logger.Errorf("query receipts of block %v failed: %v", block.header.Number, err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, hash := range matched {
+		wt, ok := w.waiters[hash]
+		if !ok {
+			continue
+		}
+		delete(w.waiters, hash)
+		wt.inc = &inclusion{hash: block.hash, header: block.header}
+		if err == nil && batch[i].Error == nil {
+			// receipt is nil if it is not available, the waiter will poll it
+			wt.inc.receipt, _ = parseReceipt(raws[i])
+		}
+		close(wt.done)
+	}
+}
+
+// wait waits for the transaction included in block until timeout, all the waiters of transaction are notified,
+// it returns nil if the transaction is not seen in time
+func (w *headWatcher) wait(hash common.Hash, timeout time.Duration) *inclusion {
+	w.mu.Lock()
+	if block, ok := w.recent[hash]; ok {
+		w.mu.Unlock()
+		return &inclusion{hash: block.hash, header: block.header}
+	}
+	wt, ok := w.waiters[hash]
+	if !ok {
+		wt = &waiter{done: make(chan struct{})}
+		w.waiters[hash] = wt
+	}
+	wt.count++
+	w.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-wt.done:
+		return wt.inc
+	case <-timer.C:
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if wt.count--; wt.count == 0 && w.waiters[hash] == wt {
+			delete(w.waiters, hash)
+		}
+		return nil
+	}
+}
+
+// confirmReceipt waits for the receipt of transaction and returns it with the header of its block if known.
+// The new heads subscription is used if it is available, otherwise the receipt is polled.
+func (e *ETH) confirmReceipt(hash common.Hash) (*receipt, *types.Header, error) {
+	if e.watcher == nil || !e.watcher.isHealthy() {
+		r, err := e.waitReceipt(hash)
+		return r, nil, err
+	}
+	inc := e.watcher.wait(hash, e.op.confirmTimeout)
+	if inc == nil {
+		// the last chance in case the block is missed
+		r, err := e.getReceipt(hash)
+		if err == ethereum.NotFound {
+			err = errReceiptTimeout
+		}
+		return r, nil, err
+	}
+	if inc.receipt != nil && inc.receipt.BlockHash == inc.hash {
+		return inc.receipt, inc.header, nil
+	}
+	r, err := e.getReceipt(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if r.BlockHash != inc.hash {
+		// the block was reorganized, the header is looked up by the receipt
+		return r, nil, nil
+	}
+	return r, inc.header, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	fcom "github.com/hyperbench/hyperbench-common/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEthService serves the blocks and receipts of a fake chain
+type fakeEthService struct {
+	header   *types.Header
+	txs      []common.Hash
+	receipts int
+}
+
+func (s *fakeEthService) block() (map[string]interface{}, error) {
+	raw, err := json.Marshal(s.header)
+	if err != nil {
+		return nil, err
+	}
+	block := make(map[string]interface{})
+	if err = json.Unmarshal(raw, &block); err != nil {
+		return nil, err
+	}
+	block["transactions"] = s.txs
+	return block, nil
+}
+
+func (s *fakeEthService) GetBlockByHash(hash common.Hash, full bool) (map[string]interface{}, error) {
+	return s.block()
+}
+
+func (s *fakeEthService) GetBlockByNumber(number string, full bool) (map[string]interface{}, error) {
+	return s.block()
+}
+
+func (s *fakeEthService) GetTransactionReceipt(hash common.Hash) (*types.Receipt, error) {
+	s.receipts++
+	return &types.Receipt{
+		Status:      types.ReceiptStatusSuccessful,
+		TxHash:      hash,
+		GasUsed:     21000,
+		BlockHash:   s.header.Hash(),
+		BlockNumber: s.header.Number,
+		Logs:        []*types.Log{},
+	}, nil
+}
+
+func TestHeadWatcher(t *testing.T) {
+	header := &types.Header{
+		Number:     big.NewInt(10),
+		Difficulty: big.NewInt(1),
+		Time:       1634515200,
+	}
+	waited := common.HexToHash("0x01")
+	service := &fakeEthService{header: header, txs: []common.Hash{waited, common.HexToHash("0x02")}}
+	server := rpc.NewServer()
+	assert.NoError(t, server.RegisterName("eth", service))
+	w := newHeadWatcher(rpc.DialInProc(server), fcom.GetLogger("eth"))
+
+	// the transaction waited twice notifies both waiters
+	done := make(chan *inclusion, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			done <- w.wait(waited, time.Second)
+		}()
+	}
+	for {
+		w.mu.Lock()
+		wt, ok := w.waiters[waited]
+		ok = ok && wt.count == 2
+		w.mu.Unlock()
+		if ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	w.process(header)
+	inc := <-done
+	assert.Equal(t, inc, <-done)
+	assert.NotNil(t, inc)
+	assert.NotNil(t, inc.receipt)
+	assert.Equal(t, header.Hash(), inc.hash)
+	assert.Equal(t, uint64(21000), inc.receipt.GasUsed)
+	// only the receipt of waited transaction is fetched
+	assert.Equal(t, 1, service.receipts)
+
+	// transaction seen before waiting is resolved by recent blocks
+	inc = w.wait(common.HexToHash("0x02"), time.Second)
+	assert.NotNil(t, inc)
+	assert.Nil(t, inc.receipt)
+	assert.Equal(t, uint64(10), inc.header.Number.Uint64())
+
+	assert.Nil(t, w.wait(common.HexToHash("0x03"), 10*time.Millisecond))
+}